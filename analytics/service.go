@@ -35,20 +35,36 @@ func NewService(repo *database.Repository, retentionDays int) *Service {
 	return s
 }
 
+// TokenUsage holds the token counts reported for a single request
+type TokenUsage struct {
+	InputTokens              int
+	OutputTokens             int
+	CacheCreationInputTokens int
+	CacheReadInputTokens     int
+}
+
+// Total returns all tokens processed for the request, including cached prompt tokens
+func (u TokenUsage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
 // RecordRequest records a new API request (async)
-func (s *Service) RecordRequest(userID uint, tokenID *uint, model, provider string, inputTokens, outputTokens int, duration time.Duration, status, errorMsg string) {
+func (s *Service) RecordRequest(userID uint, tokenID *uint, model, provider string, usage TokenUsage, duration time.Duration, status, errorMsg, rule string) {
 	log := &database.RequestLog{
-		UserID:       userID,
-		TokenID:      tokenID,
-		Model:        model,
-		Provider:     provider,
-		InputTokens:  inputTokens,
-		OutputTokens: outputTokens,
-		TotalTokens:  inputTokens + outputTokens,
-		Duration:     duration.Milliseconds(),
-		Status:       status,
-		Error:        errorMsg,
-		Timestamp:    time.Now().UTC(),
+		UserID:                   userID,
+		TokenID:                  tokenID,
+		Model:                    model,
+		Provider:                 provider,
+		InputTokens:              usage.InputTokens,
+		OutputTokens:             usage.OutputTokens,
+		CacheCreationInputTokens: usage.CacheCreationInputTokens,
+		CacheReadInputTokens:     usage.CacheReadInputTokens,
+		TotalTokens:              usage.Total(),
+		Duration:                 duration.Milliseconds(),
+		Status:                   status,
+		Error:                    errorMsg,
+		Rule:                     rule,
+		Timestamp:                time.Now().UTC(),
 	}
 
 	// Queue for async processing
@@ -101,6 +117,8 @@ func (s *Service) updateMonthlySummary(log *database.RequestLog) {
 	summary.TotalTokens += int64(log.TotalTokens)
 	summary.TotalInputTokens += int64(log.InputTokens)
 	summary.TotalOutputTokens += int64(log.OutputTokens)
+	summary.TotalCacheCreationTokens += int64(log.CacheCreationInputTokens)
+	summary.TotalCacheReadTokens += int64(log.CacheReadInputTokens)
 
 	if log.Status == "success" {
 		summary.SuccessRequests++
@@ -14,14 +14,16 @@ import (
 type AdminHandler struct {
 	analyticsService *analytics.Service
 	sessionManager   *auth.SessionManager
+	tokenManager     *auth.TokenManager
 	repo             *database.Repository
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(analyticsService *analytics.Service, sessionManager *auth.SessionManager, repo *database.Repository) *AdminHandler {
+func NewAdminHandler(analyticsService *analytics.Service, sessionManager *auth.SessionManager, tokenManager *auth.TokenManager, repo *database.Repository) *AdminHandler {
 	return &AdminHandler{
 		analyticsService: analyticsService,
 		sessionManager:   sessionManager,
+		tokenManager:     tokenManager,
 		repo:             repo,
 	}
 }
@@ -221,3 +223,45 @@ func (h *AdminHandler) HandleGetSystemAnalytics(c *gin.Context) {
 		"users":          users,
 	})
 }
+
+// HandleSetTokenRoutingOverrides allows or denies a token the x-proxy-* routing override headers
+func (h *AdminHandler) HandleSetTokenRoutingOverrides(c *gin.Context) {
+	tokenIDStr := c.Param("id")
+	tokenID, err := strconv.ParseUint(tokenIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"type":    "invalid_request",
+				"message": "invalid token ID",
+			},
+		})
+		return
+	}
+
+	var req struct {
+		Allowed *bool `json:"allowed" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"type":    "invalid_request",
+				"message": "allowed is required",
+			},
+		})
+		return
+	}
+
+	if err := h.tokenManager.SetRoutingOverrides(uint(tokenID), *req.Allowed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"type":    "server_error",
+				"message": "failed to update token",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "token routing overrides updated successfully",
+	})
+}
@@ -63,14 +63,15 @@ func (h *TokenHandler) HandleListTokens(c *gin.Context) {
 	response := make([]gin.H, len(tokens))
 	for i, token := range tokens {
 		response[i] = gin.H{
-			"id":           token.ID,
-			"name":         token.Name,
-			"prefix":       "sk-" + token.TokenPrefix + "-***",
-			"created_at":   token.CreatedAt,
-			"last_used_at": token.LastUsedAt,
-			"expires_at":   token.ExpiresAt,
-			"revoked":      token.Revoked,
-			"is_valid":     token.IsValid(),
+			"id":                token.ID,
+			"name":              token.Name,
+			"prefix":            "sk-" + token.TokenPrefix + "-***",
+			"created_at":        token.CreatedAt,
+			"last_used_at":      token.LastUsedAt,
+			"expires_at":        token.ExpiresAt,
+			"revoked":           token.Revoked,
+			"is_valid":          token.IsValid(),
+			"routing_overrides": token.RoutingOverrides,
 		}
 	}
 
@@ -135,6 +135,9 @@ func (s *Service) createMiddleware() gin.HandlerFunc {
 				// Database token is valid - store user and token info in context
 				c.Set("user_id", dbToken.UserID)
 				c.Set("token_id", dbToken.ID)
+				c.Set("token_name", dbToken.Name)
+				c.Set("routing_overrides", dbToken.RoutingOverrides)
+				c.Set("is_admin", dbToken.User.IsAdmin)
 				c.Next()
 				return
 			}
@@ -161,6 +164,30 @@ func GetUserID(c *gin.Context) (uint, bool) {
 	return id, ok
 }
 
+// GetTokenName extracts the token name from gin context
+func GetTokenName(c *gin.Context) string {
+	return c.GetString("token_name")
+}
+
+// CanOverrideRouting reports whether the caller may use the routing override headers.
+// Database tokens need the permission; static keys and unauthenticated proxies are
+// operator-level and always may.
+func CanOverrideRouting(c *gin.Context) bool {
+	if _, isToken := c.Get("token_id"); !isToken {
+		return true
+	}
+	return c.GetBool("routing_overrides")
+}
+
+// IsAdmin reports whether the caller may see operator details such as providers. Static
+// keys are operator-level; database tokens need an admin user.
+func IsAdmin(c *gin.Context) bool {
+	if _, isToken := c.Get("token_id"); !isToken {
+		return true
+	}
+	return c.GetBool("is_admin")
+}
+
 // GetTokenID extracts token ID from gin context
 func GetTokenID(c *gin.Context) (uint, bool) {
 	tokenID, exists := c.Get("token_id")
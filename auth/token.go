@@ -178,6 +178,24 @@ func (tm *TokenManager) RevokeToken(tokenID uint) error {
 	return nil
 }
 
+// SetRoutingOverrides allows or denies a token the routing override headers
+func (tm *TokenManager) SetRoutingOverrides(tokenID uint, allowed bool) error {
+	token, err := tm.repo.GetTokenByID(tokenID)
+	if err != nil {
+		return err
+	}
+
+	// Remove from cache so the change applies to the next request
+	tm.cache.Remove(token.TokenPrefix)
+
+	if err := tm.repo.SetTokenRoutingOverrides(tokenID, allowed); err != nil {
+		return fmt.Errorf("failed to update token: %w", err)
+	}
+
+	logger.Info("Updated token routing overrides", "token_id", tokenID, "allowed", allowed)
+	return nil
+}
+
 // RevokeUserTokens revokes all tokens for a user
 func (tm *TokenManager) RevokeUserTokens(userID uint) error {
 	// Get all user tokens to remove from cache
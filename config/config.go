@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Config represents the root configuration structure
 type Config struct {
 	Spec Spec `yaml:"spec"`
@@ -12,13 +18,92 @@ type Spec struct {
 	APIKeys   []string            `yaml:"apiKeys"` // Deprecated: use Auth.StaticKeys instead
 	Retry     *RetryConfig        `yaml:"retry,omitempty"`
 	Auth      *AuthConfig         `yaml:"auth,omitempty"`
+
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker,omitempty"`
+
+	// Aliases holds routing settings keyed by model name or alias pattern (e.g. "claude-sonnet*")
+	Aliases map[string]Alias `yaml:"aliases,omitempty"`
+
+	// Rules route requests by who sent them and what they contain; the first match applies
+	Rules []Rule `yaml:"rules,omitempty"`
+
+	// CountTokens sets how /v1/messages/count_tokens is answered: "upstream" (providers that
+	// support it, falling back to a local estimate, default) or "local" (always estimate locally)
+	CountTokens string `yaml:"countTokens,omitempty"`
 }
 
+// Token counting modes
+const (
+	CountTokensUpstream = "upstream"
+	CountTokensLocal    = "local"
+)
+
 // Provider represents a backend provider configuration
 type Provider struct {
-	Type     string `yaml:"type"`     // "anthropic" or "openai"
-	Endpoint string `yaml:"endpoint"`
-	APIKey   string `yaml:"apiKey"`
+	Type     string `yaml:"type"`     // "anthropic", "openai", "responses", "gemini", "bedrock" or "vertex"
+	Endpoint string `yaml:"endpoint"` // Optional for bedrock and vertex, derived from the region
+	APIKey   string `yaml:"apiKey"`   // Optional for bedrock (Bedrock API key instead of SigV4), unused for vertex
+
+	// ReasoningFormat controls how thinking budgets are sent to OpenAI providers:
+	// "effort" (reasoning_effort, default), "reasoning" (OpenRouter-style reasoning object) or "none"
+	ReasoningFormat string `yaml:"reasoningFormat,omitempty"`
+
+	// DisableStreamUsage stops asking OpenAI providers for a final usage chunk (stream_options.include_usage)
+	// when streaming, for gateways that reject stream_options; streamed token counts are then estimated
+	DisableStreamUsage bool `yaml:"disableStreamUsage,omitempty"`
+
+	// Region is the AWS region for bedrock or the Google Cloud location for vertex
+	Region string `yaml:"region,omitempty"`
+
+	// ProjectID is the Google Cloud project for vertex
+	ProjectID string `yaml:"projectId,omitempty"`
+
+	// AWS credentials for bedrock, defaulting to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	AWSAccessKeyID     string `yaml:"awsAccessKeyId,omitempty"`
+	AWSSecretAccessKey string `yaml:"awsSecretAccessKey,omitempty"`
+	AWSSessionToken    string `yaml:"awsSessionToken,omitempty"`
+
+	// CredentialsFile is the service account JSON for vertex, defaulting to GOOGLE_APPLICATION_CREDENTIALS
+	CredentialsFile string `yaml:"credentialsFile,omitempty"`
+
+	// Timeouts as durations (e.g. "10s"); firstTokenTimeout and idleStreamTimeout are disabled unless set
+	ConnectTimeout    string `yaml:"connectTimeout,omitempty"`    // Establishing the connection (default 10s)
+	FirstTokenTimeout string `yaml:"firstTokenTimeout,omitempty"` // Until the first streamed content arrives; exceeding it fails over
+	IdleStreamTimeout string `yaml:"idleStreamTimeout,omitempty"` // Longest silence between stream events
+	TotalTimeout      string `yaml:"totalTimeout,omitempty"`      // Whole request including the stream (default 10m)
+
+	// Client-side limits for all of the provider's models
+	Limits       `yaml:",inline"`
+	QueueTimeout string `yaml:"queueTimeout,omitempty"` // How long a request waits for capacity when every choice is saturated (default 10s)
+
+	// DiscoverModels registers the models the provider lists at GET /v1/models
+	DiscoverModels *DiscoverModels `yaml:"discoverModels,omitempty"`
+}
+
+// DiscoverModels controls which listed models of a provider are registered and how
+type DiscoverModels struct {
+	Interval string `yaml:"interval,omitempty"` // How often the list is refreshed (default 1h)
+
+	// Models pick the listed models to register by ID pattern; the first match applies and
+	// its settings (alias, context, weight, capabilities, ...) are used for the model.
+	// Without entries every listed model is registered under its own name.
+	Models []DiscoveredModel `yaml:"models,omitempty"`
+}
+
+// DiscoveredModel registers the listed models whose ID matches a pattern
+type DiscoveredModel struct {
+	Match string `yaml:"match"` // Model ID pattern, e.g. "claude-sonnet-*"
+
+	// Settings for the matching models; name and provider are filled in, and a context
+	// reported by the provider takes precedence
+	Model `yaml:",inline"`
+}
+
+// Limits are client-side request limits for a provider or model; zero means unlimited
+type Limits struct {
+	MaxConcurrent     int `yaml:"maxConcurrent,omitempty"`     // Requests in flight at once
+	RequestsPerMinute int `yaml:"requestsPerMinute,omitempty"` // Requests started per minute
+	TokensPerMinute   int `yaml:"tokensPerMinute,omitempty"`   // Input and output tokens per minute
 }
 
 // GetType returns the provider type, defaulting to "anthropic" if not set
@@ -29,6 +114,17 @@ func (p *Provider) GetType() string {
 	return p.Type
 }
 
+// GetEndpoint returns the endpoint, letting the provider type derive one if not set
+func (p *Provider) GetEndpoint() string {
+	if p.Endpoint != "" {
+		return strings.TrimSuffix(p.Endpoint, "/")
+	}
+	if providerType, exists := lookupProviderType(p.GetType()); exists && providerType.DefaultEndpoint != nil {
+		return providerType.DefaultEndpoint(*p)
+	}
+	return ""
+}
+
 // Model represents a model configuration
 type Model struct {
 	Name     string `yaml:"name"`
@@ -37,6 +133,28 @@ type Model struct {
 	Provider string `yaml:"provider"`
 	Weight   int    `yaml:"weight"`
 	Thinking bool   `yaml:"thinking"`
+
+	DisplayName string `yaml:"displayName,omitempty"` // Human-readable name listed by /v1/models
+
+	// Capabilities lists what the model supports (tools, vision, pdf, thinking, prompt-caching).
+	// When empty every capability except thinking, which follows Thinking, is assumed.
+	Capabilities    []string `yaml:"capabilities,omitempty"`
+	MaxOutputTokens int      `yaml:"maxOutputTokens,omitempty"` // Largest max_tokens the model accepts; 0 means no limit
+
+	// Hedge races the next choice when this model is slow to stream its first token
+	Hedge           bool    `yaml:"hedge,omitempty"`
+	HedgeMultiplier float64 `yaml:"hedgeMultiplier,omitempty"` // Multiple of the average time to first token to wait (default 2)
+	HedgeDelay      string  `yaml:"hedgeDelay,omitempty"`      // Wait used until a time to first token is known (default 2s)
+
+	// Client-side limits for this model on its provider, on top of the provider's own
+	Limits `yaml:",inline"`
+
+	// Price in USD per million tokens, used by the lowest-cost routing strategy
+	InputCost  float64 `yaml:"inputCost,omitempty"`
+	OutputCost float64 `yaml:"outputCost,omitempty"`
+
+	Discovered bool      `yaml:"-"` // Registered from the provider's model list rather than configured
+	CreatedAt  time.Time `yaml:"-"` // Release date from the provider's model list; zero if unknown
 }
 
 // GetWeight returns the weight with a default of 1 if not set
@@ -47,6 +165,175 @@ func (m *Model) GetWeight() int {
 	return m.Weight
 }
 
+// Model capabilities a request can depend on
+const (
+	CapabilityTools         = "tools"
+	CapabilityVision        = "vision"
+	CapabilityPDF           = "pdf"
+	CapabilityThinking      = "thinking"
+	CapabilityPromptCaching = "prompt-caching"
+)
+
+// IsCapability reports whether name is a known model capability
+func IsCapability(name string) bool {
+	switch name {
+	case CapabilityTools, CapabilityVision, CapabilityPDF, CapabilityThinking, CapabilityPromptCaching:
+		return true
+	}
+	return false
+}
+
+// HasCapability reports whether the model supports a capability
+func (m *Model) HasCapability(name string) bool {
+	for _, capability := range m.Capabilities {
+		if capability == name {
+			return true
+		}
+	}
+	if name == CapabilityThinking {
+		return m.Thinking
+	}
+	return len(m.Capabilities) == 0
+}
+
+// HedgeAfter returns how long to wait for a first token before hedging, given the
+// average time to first token seen so far (0 when unknown)
+func (m *Model) HedgeAfter(averageTTFT time.Duration) time.Duration {
+	if averageTTFT > 0 {
+		multiplier := m.HedgeMultiplier
+		if multiplier <= 0 {
+			multiplier = 2
+		}
+		return time.Duration(float64(averageTTFT) * multiplier)
+	}
+	if delay, err := time.ParseDuration(m.HedgeDelay); err == nil && delay > 0 {
+		return delay
+	}
+	return 2 * time.Second
+}
+
+// Routing strategies for ordering the providers of an alias
+const (
+	StrategyPriority       = "priority"        // Highest weight first, then highest TPS (default)
+	StrategyWeightedRandom = "weighted-random" // Random order with chances proportional to weight
+	StrategyRoundRobin     = "round-robin"     // Rotate the first choice on every request
+	StrategyLeastLatency   = "least-latency"   // Lowest moving average time to first token first
+	StrategyLeastInflight  = "least-inflight"  // Fewest requests in flight first
+	StrategyLowestCost     = "lowest-cost"     // Cheapest input and output price first
+)
+
+// Alias represents routing settings shared by the models behind a model name or alias
+type Alias struct {
+	Strategy string `yaml:"strategy,omitempty"` // How choices are ordered (default "priority")
+
+	// CapabilityPolicy decides what happens when no model supports everything a request uses:
+	// "degrade" (default) strips what can be stripped and tries the models anyway, "fail" rejects the request
+	CapabilityPolicy string `yaml:"capabilityPolicy,omitempty"`
+
+	// FallbackAliases are tried in order when the alias cannot serve a request
+	FallbackAliases []FallbackAlias `yaml:"fallbackAliases,omitempty"`
+}
+
+// FallbackAlias is another model name or alias to degrade to
+type FallbackAlias struct {
+	Alias string   `yaml:"alias"`
+	When  []string `yaml:"when,omitempty"` // Conditions that trigger it (default: all of them)
+}
+
+// Conditions under which a request falls back to another alias
+const (
+	FallbackAllFailed       = "all-failed"        // Every provider of the alias failed
+	FallbackOverBudget      = "over-budget"       // Every failure was a rate limit or client-side limit
+	FallbackContextTooLarge = "context-too-large" // No model of the alias has a large enough context window
+)
+
+// Triggers reports whether the fallback applies to any of the conditions
+func (f *FallbackAlias) Triggers(conditions []string) bool {
+	if len(f.When) == 0 {
+		return len(conditions) > 0
+	}
+	for _, when := range f.When {
+		for _, condition := range conditions {
+			if when == condition {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetStrategy returns the routing strategy, defaulting to priority
+func (a *Alias) GetStrategy() string {
+	if a.Strategy == "" {
+		return StrategyPriority
+	}
+	return a.Strategy
+}
+
+// Capability policies for requests no model fully supports
+const (
+	CapabilityPolicyDegrade = "degrade"
+	CapabilityPolicyFail    = "fail"
+)
+
+// GetCapabilityPolicy returns the capability policy, defaulting to degrade
+func (a *Alias) GetCapabilityPolicy() string {
+	if a.CapabilityPolicy == "" {
+		return CapabilityPolicyDegrade
+	}
+	return a.CapabilityPolicy
+}
+
+// IsStrategy reports whether name is a known routing strategy
+func IsStrategy(name string) bool {
+	switch name {
+	case StrategyPriority, StrategyWeightedRandom, StrategyRoundRobin,
+		StrategyLeastLatency, StrategyLeastInflight, StrategyLowestCost:
+		return true
+	}
+	return false
+}
+
+// Rule changes how matching requests are routed
+type Rule struct {
+	Name  string    `yaml:"name"`
+	Match RuleMatch `yaml:"match"`
+
+	Model     string   `yaml:"model,omitempty"`     // Route to this model name or alias instead of the requested one
+	Providers []string `yaml:"providers,omitempty"` // Only use models on these providers
+	Strategy  string   `yaml:"strategy,omitempty"`  // Routing strategy instead of the alias's
+}
+
+// RuleMatch holds the conditions of a rule; every condition set must hold
+type RuleMatch struct {
+	Models         []string          `yaml:"models,omitempty"`         // Requested model name or alias patterns (wildcards allowed)
+	Users          []uint            `yaml:"users,omitempty"`          // User IDs of database tokens
+	Tokens         []string          `yaml:"tokens,omitempty"`         // Token name patterns (wildcards allowed)
+	Headers        map[string]string `yaml:"headers,omitempty"`        // Header value patterns (wildcards allowed; "*" means present)
+	SystemPrompt   string            `yaml:"systemPrompt,omitempty"`   // Regular expression matched against the system prompt
+	Tools          *bool             `yaml:"tools,omitempty"`          // Whether the request defines tools
+	MinInputTokens int               `yaml:"minInputTokens,omitempty"` // Estimated prompt size bounds
+	MaxInputTokens int               `yaml:"maxInputTokens,omitempty"`
+	TimeOfDay      string            `yaml:"timeOfDay,omitempty"` // Local time window such as "22:00-06:00"
+}
+
+// ParseTimeOfDay parses a "15:04-15:04" window into minutes after midnight
+func ParseTimeOfDay(window string) (int, int, error) {
+	from, to, found := strings.Cut(window, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("time window must look like 09:00-17:00, got '%s'", window)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start time in '%s'", window)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end time in '%s'", window)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
 // RetryConfig represents retry configuration
 type RetryConfig struct {
 	MaxRetries        int     `yaml:"maxRetries"`
@@ -54,6 +341,24 @@ type RetryConfig struct {
 	MaxDelay          string  `yaml:"maxDelay"`
 	BackoffMultiplier float64 `yaml:"backoffMultiplier"`
 	RetrySameProvider bool    `yaml:"retrySameProvider"`
+
+	// StreamCommit sets when a stream is committed to the client: "content" (first content delta, default)
+	// or "response" (as soon as the provider answers). A stream failing before then fails over to the next provider.
+	StreamCommit string `yaml:"streamCommit,omitempty"`
+
+	// StreamCommitAfter commits a stream that has produced no content yet after this long (e.g. "5s")
+	StreamCommitAfter string `yaml:"streamCommitAfter,omitempty"`
+
+	// MaxRetryAfter caps how long a same-provider retry waits for a provider's Retry-After or rate limit reset (default 30s)
+	MaxRetryAfter string `yaml:"maxRetryAfter,omitempty"`
+}
+
+// CircuitBreakerConfig controls when failing provider/model combinations are taken out of rotation
+type CircuitBreakerConfig struct {
+	Window      string  `yaml:"window,omitempty"`      // Rolling window outcomes are counted over (default 5m)
+	MinRequests int     `yaml:"minRequests,omitempty"` // Requests in the window before the breaker can open (default 10)
+	ErrorRate   float64 `yaml:"errorRate,omitempty"`   // Error rate in the window that opens the breaker (default 0.5)
+	Cooldown    string  `yaml:"cooldown,omitempty"`    // How long an open breaker waits before a probe request (default 30s)
 }
 
 // AuthConfig represents authentication configuration
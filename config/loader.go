@@ -39,6 +39,15 @@ func (c *Config) resolveValues() error {
 			return fmt.Errorf("failed to resolve API key for provider %s: %w", name, err)
 		}
 		provider.APIKey = resolved
+
+		// Resolve cloud credentials, which may also come from the environment
+		for _, field := range []*string{&provider.AWSAccessKeyID, &provider.AWSSecretAccessKey, &provider.AWSSessionToken, &provider.CredentialsFile} {
+			resolved, err := resolveValue(*field)
+			if err != nil {
+				return fmt.Errorf("failed to resolve credentials for provider %s: %w", name, err)
+			}
+			*field = resolved
+		}
 		c.Spec.Providers[name] = provider
 	}
 
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// ProviderType describes the configuration rules of one provider type. Provider
+// types are registered by the provider package alongside their adapters, so a
+// new backend only has to be added in one place.
+type ProviderType struct {
+	// Validate checks type-specific settings; nil requires an endpoint and an API key
+	Validate func(p Provider) error
+
+	// DefaultEndpoint derives the endpoint when none is configured; may be nil
+	DefaultEndpoint func(p Provider) string
+
+	// DiscoverModels reports that the provider lists its models at GET /v1/models
+	DiscoverModels bool
+}
+
+var (
+	providerTypesMu sync.RWMutex
+	providerTypes   = make(map[string]ProviderType)
+)
+
+// RegisterProviderType makes a provider type valid in configuration
+func RegisterProviderType(name string, providerType ProviderType) {
+	providerTypesMu.Lock()
+	defer providerTypesMu.Unlock()
+	providerTypes[name] = providerType
+}
+
+// lookupProviderType returns the registered rules for a provider type
+func lookupProviderType(name string) (ProviderType, bool) {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+	providerType, exists := providerTypes[name]
+	return providerType, exists
+}
+
+// ProviderTypeNames returns the registered provider types in sorted order
+func ProviderTypeNames() []string {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+
+	names := make([]string, 0, len(providerTypes))
+	for name := range providerTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
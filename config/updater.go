@@ -3,6 +3,7 @@ package config
 import (
 	"anthropic-proxy/logger"
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -15,6 +16,8 @@ type ProviderManager interface {
 // ModelRegistry interface for updating models
 type ModelRegistry interface {
 	UpdateModels(models []Model)
+	UpdateAliases(aliases map[string]Alias)
+	UpdateRules(rules []Rule)
 	Size() int
 }
 
@@ -212,7 +215,7 @@ func (u *ConfigUpdater) calculateChanges(oldConfig, newConfig *Config) []ConfigC
 	// Updated providers
 	for name, newProvider := range newProviders {
 		if oldProvider, exists := oldProviders[name]; exists {
-			if oldProvider.Endpoint != newProvider.Endpoint || oldProvider.APIKey != newProvider.APIKey {
+			if !reflect.DeepEqual(oldProvider, newProvider) {
 				desc := fmt.Sprintf("Provider '%s'", name)
 				if oldProvider.Endpoint != newProvider.Endpoint {
 					desc += fmt.Sprintf(" endpoint: %s → %s", oldProvider.Endpoint, newProvider.Endpoint)
@@ -220,6 +223,20 @@ func (u *ConfigUpdater) calculateChanges(oldConfig, newConfig *Config) []ConfigC
 				if oldProvider.APIKey != newProvider.APIKey {
 					desc += " API key updated"
 				}
+				if oldProvider.ReasoningFormat != newProvider.ReasoningFormat {
+					desc += fmt.Sprintf(" reasoning format: %s → %s", oldProvider.ReasoningFormat, newProvider.ReasoningFormat)
+				}
+				if oldProvider.DisableStreamUsage != newProvider.DisableStreamUsage {
+					desc += fmt.Sprintf(" stream usage disabled: %t → %t", oldProvider.DisableStreamUsage, newProvider.DisableStreamUsage)
+				}
+				if oldProvider.Region != newProvider.Region || oldProvider.ProjectID != newProvider.ProjectID ||
+					oldProvider.AWSAccessKeyID != newProvider.AWSAccessKeyID || oldProvider.AWSSecretAccessKey != newProvider.AWSSecretAccessKey ||
+					oldProvider.AWSSessionToken != newProvider.AWSSessionToken || oldProvider.CredentialsFile != newProvider.CredentialsFile {
+					desc += " cloud settings updated"
+				}
+				if !reflect.DeepEqual(oldProvider.DiscoverModels, newProvider.DiscoverModels) {
+					desc += " model discovery updated"
+				}
 				changes = append(changes, ConfigChange{
 					Type:        "provider",
 					Action:      "updated",
@@ -245,6 +262,26 @@ func (u *ConfigUpdater) calculateChanges(oldConfig, newConfig *Config) []ConfigC
 		})
 	}
 
+	// Alias routing changes
+	if !reflect.DeepEqual(oldConfig.Spec.Aliases, newConfig.Spec.Aliases) {
+		changes = append(changes, ConfigChange{
+			Type:        "alias",
+			Action:      "changed",
+			Name:        "aliases",
+			Description: fmt.Sprintf("Alias routing settings: %d → %d", len(oldConfig.Spec.Aliases), len(newConfig.Spec.Aliases)),
+		})
+	}
+
+	// Routing rule changes
+	if !reflect.DeepEqual(oldConfig.Spec.Rules, newConfig.Spec.Rules) {
+		changes = append(changes, ConfigChange{
+			Type:        "rule",
+			Action:      "changed",
+			Name:        "rules",
+			Description: fmt.Sprintf("Routing rules: %d → %d", len(oldConfig.Spec.Rules), len(newConfig.Spec.Rules)),
+		})
+	}
+
 	// API key changes - create maps for easier comparison
 	oldKeys := oldConfig.Spec.APIKeys
 	newKeys := newConfig.Spec.APIKeys
@@ -340,6 +377,16 @@ func (u *ConfigUpdater) calculateChanges(oldConfig, newConfig *Config) []ConfigC
 		}
 	}
 
+	// Token counting mode changes
+	if oldConfig.Spec.CountTokens != newConfig.Spec.CountTokens {
+		changes = append(changes, ConfigChange{
+			Type:        "countTokens",
+			Action:      "changed",
+			Name:        "countTokens",
+			Description: fmt.Sprintf("Token counting: %q → %q", oldConfig.Spec.CountTokens, newConfig.Spec.CountTokens),
+		})
+	}
+
 	return changes
 }
 
@@ -382,6 +429,8 @@ func (u *ConfigUpdater) applyConfig(newConfig *Config) error {
 
 	// Update models
 	u.modelRegistry.UpdateModels(newConfig.Spec.Models)
+	u.modelRegistry.UpdateAliases(newConfig.Spec.Aliases)
+	u.modelRegistry.UpdateRules(newConfig.Spec.Rules)
 
 	// Update API keys
 	if u.authService != nil {
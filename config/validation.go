@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
 // Validate checks if the configuration is valid
@@ -19,8 +22,8 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	// Check if we have at least one model
-	if len(c.Spec.Models) == 0 {
+	// Check if we have at least one model, or a provider to discover them from
+	if len(c.Spec.Models) == 0 && !c.discoversModels() {
 		return fmt.Errorf("at least one model must be configured")
 	}
 
@@ -31,6 +34,49 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate alias routing settings
+	for name, alias := range c.Spec.Aliases {
+		if err := validateAlias(alias); err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+	}
+
+	// Validate routing rules
+	ruleNames := make(map[string]bool, len(c.Spec.Rules))
+	for i, rule := range c.Spec.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule at index %d: name cannot be empty", i)
+		}
+		if ruleNames[rule.Name] {
+			return fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		ruleNames[rule.Name] = true
+		if err := c.validateRule(rule); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+
+	// Validate retry and failover configuration
+	if c.Spec.Retry != nil {
+		if err := validateRetryConfig(*c.Spec.Retry); err != nil {
+			return fmt.Errorf("retry: %w", err)
+		}
+	}
+
+	// Validate circuit breaker configuration
+	if c.Spec.CircuitBreaker != nil {
+		if err := validateCircuitBreakerConfig(*c.Spec.CircuitBreaker); err != nil {
+			return fmt.Errorf("circuitBreaker: %w", err)
+		}
+	}
+
+	// Validate token counting mode
+	switch c.Spec.CountTokens {
+	case "", CountTokensUpstream, CountTokensLocal:
+	default:
+		return fmt.Errorf("countTokens must be %q or %q", CountTokensUpstream, CountTokensLocal)
+	}
+
 	// Validate authentication configuration
 	if err := c.validateAuth(); err != nil {
 		return err
@@ -39,6 +85,112 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validateRetryConfig validates retry and stream failover settings
+func validateRetryConfig(cfg RetryConfig) error {
+	switch cfg.StreamCommit {
+	case "", "content", "response":
+	default:
+		return fmt.Errorf("streamCommit must be 'content' or 'response', got '%s'", cfg.StreamCommit)
+	}
+
+	if cfg.StreamCommitAfter != "" {
+		if _, err := time.ParseDuration(cfg.StreamCommitAfter); err != nil {
+			return fmt.Errorf("invalid streamCommitAfter: %w", err)
+		}
+	}
+
+	if cfg.MaxRetryAfter != "" {
+		if _, err := time.ParseDuration(cfg.MaxRetryAfter); err != nil {
+			return fmt.Errorf("invalid maxRetryAfter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateAlias validates alias routing settings
+func validateAlias(a Alias) error {
+	if a.Strategy != "" && !IsStrategy(a.Strategy) {
+		return fmt.Errorf("unknown strategy '%s'", a.Strategy)
+	}
+
+	switch a.CapabilityPolicy {
+	case "", CapabilityPolicyDegrade, CapabilityPolicyFail:
+	default:
+		return fmt.Errorf("capabilityPolicy must be 'degrade' or 'fail', got '%s'", a.CapabilityPolicy)
+	}
+
+	for _, fallback := range a.FallbackAliases {
+		if fallback.Alias == "" {
+			return fmt.Errorf("fallbackAliases: alias cannot be empty")
+		}
+		for _, when := range fallback.When {
+			switch when {
+			case FallbackAllFailed, FallbackOverBudget, FallbackContextTooLarge:
+			default:
+				return fmt.Errorf("fallbackAliases: unknown condition '%s'", when)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRule validates a routing rule
+func (c *Config) validateRule(r Rule) error {
+	if r.Strategy != "" && !IsStrategy(r.Strategy) {
+		return fmt.Errorf("unknown strategy '%s'", r.Strategy)
+	}
+
+	for _, name := range r.Providers {
+		if _, exists := c.Spec.Providers[name]; !exists {
+			return fmt.Errorf("provider %s does not exist", name)
+		}
+	}
+
+	if r.Match.SystemPrompt != "" {
+		if _, err := regexp.Compile(r.Match.SystemPrompt); err != nil {
+			return fmt.Errorf("invalid systemPrompt pattern: %w", err)
+		}
+	}
+
+	if r.Match.MinInputTokens < 0 || r.Match.MaxInputTokens < 0 {
+		return fmt.Errorf("minInputTokens and maxInputTokens cannot be negative")
+	}
+	if r.Match.MaxInputTokens > 0 && r.Match.MinInputTokens > r.Match.MaxInputTokens {
+		return fmt.Errorf("minInputTokens cannot exceed maxInputTokens")
+	}
+
+	if r.Match.TimeOfDay != "" {
+		if _, _, err := ParseTimeOfDay(r.Match.TimeOfDay); err != nil {
+			return fmt.Errorf("timeOfDay: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateCircuitBreakerConfig validates circuit breaker settings
+func validateCircuitBreakerConfig(cfg CircuitBreakerConfig) error {
+	for field, value := range map[string]string{"window": cfg.Window, "cooldown": cfg.Cooldown} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got '%s'", field, value)
+		}
+	}
+
+	if cfg.MinRequests < 0 {
+		return fmt.Errorf("minRequests cannot be negative")
+	}
+
+	if cfg.ErrorRate < 0 || cfg.ErrorRate > 1 {
+		return fmt.Errorf("errorRate must be between 0 and 1, got %v", cfg.ErrorRate)
+	}
+
+	return nil
+}
+
 // validateAuth validates authentication configuration
 func (c *Config) validateAuth() error {
 	// Check if we have at least one authentication method
@@ -178,28 +330,89 @@ func validateProvider(name string, p Provider) error {
 		return fmt.Errorf("provider name cannot be empty")
 	}
 
-	// Validate provider type
-	providerType := p.GetType()
-	if providerType != "anthropic" && providerType != "openai" {
-		return fmt.Errorf("provider %s: type must be either 'anthropic' or 'openai', got '%s'", name, providerType)
+	// Validate provider type against the registered provider adapters
+	providerType, exists := lookupProviderType(p.GetType())
+	if !exists {
+		return fmt.Errorf("provider %s: type must be one of '%s', got '%s'", name, strings.Join(ProviderTypeNames(), "', '"), p.GetType())
+	}
+
+	switch p.ReasoningFormat {
+	case "", "effort", "reasoning", "none":
+	default:
+		return fmt.Errorf("provider %s: reasoningFormat must be one of 'effort', 'reasoning' or 'none', got '%s'", name, p.ReasoningFormat)
 	}
 
-	if p.Endpoint == "" {
-		return fmt.Errorf("provider %s: endpoint cannot be empty", name)
+	// Provider types with their own credentials or endpoints validate their settings themselves
+	if providerType.Validate != nil {
+		if err := providerType.Validate(p); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+	} else {
+		if p.Endpoint == "" {
+			return fmt.Errorf("provider %s: endpoint cannot be empty", name)
+		}
+		if p.APIKey == "" {
+			return fmt.Errorf("provider %s: API key cannot be empty", name)
+		}
 	}
 
 	// Validate endpoint is a valid URL
-	if _, err := url.Parse(p.Endpoint); err != nil {
+	if _, err := url.Parse(p.GetEndpoint()); err != nil {
 		return fmt.Errorf("provider %s: invalid endpoint URL: %w", name, err)
 	}
 
-	if p.APIKey == "" {
-		return fmt.Errorf("provider %s: API key cannot be empty", name)
+	// Validate timeouts
+	timeouts := []struct{ field, value string }{
+		{"connectTimeout", p.ConnectTimeout},
+		{"firstTokenTimeout", p.FirstTokenTimeout},
+		{"idleStreamTimeout", p.IdleStreamTimeout},
+		{"totalTimeout", p.TotalTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(timeout.value); err != nil || d < 0 {
+			return fmt.Errorf("provider %s: %s must be a non-negative duration, got '%s'", name, timeout.field, timeout.value)
+		}
+	}
+
+	if err := validateLimits(p.Limits); err != nil {
+		return fmt.Errorf("provider %s: %w", name, err)
+	}
+
+	if p.QueueTimeout != "" {
+		if d, err := time.ParseDuration(p.QueueTimeout); err != nil || d < 0 {
+			return fmt.Errorf("provider %s: queueTimeout must be a non-negative duration, got '%s'", name, p.QueueTimeout)
+		}
+	}
+
+	if p.DiscoverModels != nil {
+		if !providerType.DiscoverModels {
+			return fmt.Errorf("provider %s: discoverModels is not supported for %s providers", name, p.GetType())
+		}
+		if err := validateDiscoverModels(*p.DiscoverModels); err != nil {
+			return fmt.Errorf("provider %s: discoverModels: %w", name, err)
+		}
 	}
 
 	return nil
 }
 
+// validateLimits checks client-side request limits
+func validateLimits(l Limits) error {
+	if l.MaxConcurrent < 0 {
+		return fmt.Errorf("maxConcurrent cannot be negative")
+	}
+	if l.RequestsPerMinute < 0 {
+		return fmt.Errorf("requestsPerMinute cannot be negative")
+	}
+	if l.TokensPerMinute < 0 {
+		return fmt.Errorf("tokensPerMinute cannot be negative")
+	}
+	return nil
+}
+
 // validateModel checks if a model configuration is valid
 func (c *Config) validateModel(index int, m Model) error {
 	if m.Name == "" {
@@ -219,9 +432,83 @@ func (c *Config) validateModel(index int, m Model) error {
 		return fmt.Errorf("model %s: context must be positive", m.Name)
 	}
 
+	if err := validateModelSettings(m); err != nil {
+		return fmt.Errorf("model %s: %w", m.Name, err)
+	}
+
+	return nil
+}
+
+// validateModelSettings checks the routing settings shared by configured and discovered models
+func validateModelSettings(m Model) error {
+	if m.Context < 0 {
+		return fmt.Errorf("context cannot be negative")
+	}
+
 	if m.Weight < 0 {
-		return fmt.Errorf("model %s: weight cannot be negative", m.Name)
+		return fmt.Errorf("weight cannot be negative")
+	}
+
+	if err := validateLimits(m.Limits); err != nil {
+		return err
+	}
+
+	for _, capability := range m.Capabilities {
+		if !IsCapability(capability) {
+			return fmt.Errorf("unknown capability '%s'", capability)
+		}
+	}
+
+	if m.MaxOutputTokens < 0 {
+		return fmt.Errorf("maxOutputTokens cannot be negative")
+	}
+
+	if m.InputCost < 0 || m.OutputCost < 0 {
+		return fmt.Errorf("inputCost and outputCost cannot be negative")
+	}
+
+	if m.HedgeMultiplier < 0 {
+		return fmt.Errorf("hedgeMultiplier cannot be negative")
+	}
+
+	if m.HedgeDelay != "" {
+		if d, err := time.ParseDuration(m.HedgeDelay); err != nil || d <= 0 {
+			return fmt.Errorf("hedgeDelay must be a positive duration, got '%s'", m.HedgeDelay)
+		}
 	}
 
 	return nil
 }
+
+// validateDiscoverModels checks a provider's model discovery settings
+func validateDiscoverModels(d DiscoverModels) error {
+	if d.Interval != "" {
+		if interval, err := time.ParseDuration(d.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("interval must be a positive duration, got '%s'", d.Interval)
+		}
+	}
+
+	for i, m := range d.Models {
+		if m.Match == "" {
+			return fmt.Errorf("model at index %d: match cannot be empty", i)
+		}
+		if m.Name != "" || m.Provider != "" {
+			return fmt.Errorf("model %s: name and provider are taken from the listed model", m.Match)
+		}
+		if err := validateModelSettings(m.Model); err != nil {
+			return fmt.Errorf("model %s: %w", m.Match, err)
+		}
+	}
+
+	return nil
+}
+
+// discoversModels reports whether any provider registers the models it lists
+func (c *Config) discoversModels() bool {
+	for _, provider := range c.Spec.Providers {
+		if provider.DiscoverModels != nil {
+			return true
+		}
+	}
+	return false
+}
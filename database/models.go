@@ -23,18 +23,19 @@ type User struct {
 
 // Token represents an API token
 type Token struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	UserID      uint           `gorm:"index;not null" json:"user_id"`
-	TokenHash   string         `gorm:"uniqueIndex;not null" json:"-"`          // bcrypt hash of full token
-	TokenPrefix string         `gorm:"index;not null;size:16" json:"prefix"`   // First 8 chars for display/lookup
-	Name        string         `gorm:"size:255" json:"name"`                   // User-friendly name
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	LastUsedAt  *time.Time     `json:"last_used_at,omitempty"`
-	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
-	Revoked     bool           `gorm:"default:false;index" json:"revoked"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-	User        User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	UserID           uint           `gorm:"index;not null" json:"user_id"`
+	TokenHash        string         `gorm:"uniqueIndex;not null" json:"-"`        // bcrypt hash of full token
+	TokenPrefix      string         `gorm:"index;not null;size:16" json:"prefix"` // First 8 chars for display/lookup
+	Name             string         `gorm:"size:255" json:"name"`                 // User-friendly name
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	LastUsedAt       *time.Time     `json:"last_used_at,omitempty"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty"`
+	Revoked          bool           `gorm:"default:false;index" json:"revoked"`
+	RoutingOverrides bool           `gorm:"default:false" json:"routing_overrides"` // May steer routing with the x-proxy-* headers
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	User             User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
 // TableName overrides the table name for User
@@ -71,19 +72,22 @@ func (t *Token) IsValid() bool {
 
 // RequestLog represents a detailed log of an API request
 type RequestLog struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	UserID       uint      `gorm:"index;not null" json:"user_id"`
-	TokenID      *uint     `gorm:"index" json:"token_id,omitempty"`
-	Model        string    `gorm:"index;size:100" json:"model"`
-	Provider     string    `gorm:"index;size:100" json:"provider"`
-	InputTokens  int       `json:"input_tokens"`
-	OutputTokens int       `json:"output_tokens"`
-	TotalTokens  int       `gorm:"index" json:"total_tokens"`
-	Duration     int64     `json:"duration"` // Duration in milliseconds
-	Status       string    `gorm:"index;size:20" json:"status"` // "success" or "error"
-	Error        string    `gorm:"size:500" json:"error,omitempty"`
-	Timestamp    time.Time `gorm:"index;not null" json:"timestamp"`
-	User         User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ID                       uint      `gorm:"primaryKey" json:"id"`
+	UserID                   uint      `gorm:"index;not null" json:"user_id"`
+	TokenID                  *uint     `gorm:"index" json:"token_id,omitempty"`
+	Model                    string    `gorm:"index;size:100" json:"model"`
+	Provider                 string    `gorm:"index;size:100" json:"provider"`
+	InputTokens              int       `json:"input_tokens"`
+	OutputTokens             int       `json:"output_tokens"`
+	CacheCreationInputTokens int       `json:"cache_creation_input_tokens"` // Prompt tokens written to the provider cache
+	CacheReadInputTokens     int       `json:"cache_read_input_tokens"`     // Prompt tokens served from the provider cache
+	TotalTokens              int       `gorm:"index" json:"total_tokens"`
+	Duration                 int64     `json:"duration"`                    // Duration in milliseconds
+	Status                   string    `gorm:"index;size:20" json:"status"` // "success" or "error"
+	Error                    string    `gorm:"size:500" json:"error,omitempty"`
+	Rule                     string    `gorm:"index;size:100" json:"rule,omitempty"` // Routing rule that matched the request
+	Timestamp                time.Time `gorm:"index;not null" json:"timestamp"`
+	User                     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
 // TableName overrides the table name for RequestLog
@@ -93,21 +97,23 @@ func (RequestLog) TableName() string {
 
 // UsageSummary represents aggregated monthly usage statistics
 type UsageSummary struct {
-	ID               uint      `gorm:"primaryKey" json:"id"`
-	UserID           uint      `gorm:"index;not null" json:"user_id"`
-	Year             int       `gorm:"index;not null" json:"year"`
-	Month            int       `gorm:"index;not null" json:"month"`
-	TotalRequests    int64     `json:"total_requests"`
-	SuccessRequests  int64     `json:"success_requests"`
-	ErrorRequests    int64     `json:"error_requests"`
-	TotalTokens      int64     `json:"total_tokens"`
-	TotalInputTokens int64     `json:"total_input_tokens"`
-	TotalOutputTokens int64     `json:"total_output_tokens"`
-	Models           string    `gorm:"type:text" json:"models"` // JSON map of model -> count
-	Providers        string    `gorm:"type:text" json:"providers"` // JSON map of provider -> count
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
-	User             User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ID                       uint      `gorm:"primaryKey" json:"id"`
+	UserID                   uint      `gorm:"index;not null" json:"user_id"`
+	Year                     int       `gorm:"index;not null" json:"year"`
+	Month                    int       `gorm:"index;not null" json:"month"`
+	TotalRequests            int64     `json:"total_requests"`
+	SuccessRequests          int64     `json:"success_requests"`
+	ErrorRequests            int64     `json:"error_requests"`
+	TotalTokens              int64     `json:"total_tokens"`
+	TotalInputTokens         int64     `json:"total_input_tokens"`
+	TotalOutputTokens        int64     `json:"total_output_tokens"`
+	TotalCacheCreationTokens int64     `json:"total_cache_creation_tokens"`
+	TotalCacheReadTokens     int64     `json:"total_cache_read_tokens"`
+	Models                   string    `gorm:"type:text" json:"models"`    // JSON map of model -> count
+	Providers                string    `gorm:"type:text" json:"providers"` // JSON map of provider -> count
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+	User                     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
 // TableName overrides the table name for UsageSummary
@@ -95,7 +95,7 @@ func (r *Repository) GetTokenByID(id uint) (*Token, error) {
 // GetTokenByPrefix retrieves a token by prefix
 func (r *Repository) GetTokenByPrefix(prefix string) (*Token, error) {
 	var token Token
-	err := r.db.Where("token_prefix = ? AND revoked = ? AND deleted_at IS NULL", prefix, false).First(&token).Error
+	err := r.db.Preload("User").Where("token_prefix = ? AND revoked = ? AND deleted_at IS NULL", prefix, false).First(&token).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, ErrTokenNotFound
 	}
@@ -140,6 +140,11 @@ func (r *Repository) RevokeToken(id uint) error {
 	return r.db.Model(&Token{}).Where("id = ?", id).Update("revoked", true).Error
 }
 
+// SetTokenRoutingOverrides allows or denies a token the routing override headers
+func (r *Repository) SetTokenRoutingOverrides(id uint, allowed bool) error {
+	return r.db.Model(&Token{}).Where("id = ?", id).Update("routing_overrides", allowed).Error
+}
+
 // RevokeTokensByUserID revokes all tokens for a user
 func (r *Repository) RevokeTokensByUserID(userID uint) error {
 	return r.db.Model(&Token{}).Where("user_id = ?", userID).Update("revoked", true).Error
@@ -41,8 +41,13 @@ func main() {
 	watch := flag.Bool("watch", false, "Watch for config file changes and prompt to reload")
 	logFile := flag.String("log-file", "", "Path to file for logging all requests and responses")
 	tpsThreshold := flag.Float64("tps-threshold", 40.0, "Minimum TPS threshold for provider selection")
+	slowProviders := flag.String("slow-providers", "exclude", "What to do with providers below the TPS threshold: exclude (unless all are slow) or last (try them after the others)")
 	flag.Parse()
 
+	if *slowProviders != "exclude" && *slowProviders != "last" {
+		log.Fatalf("Invalid -slow-providers %q: must be exclude or last", *slowProviders)
+	}
+
 	// Initialize logger
 	logLevel := os.Getenv("LOG_LEVEL")
 	if logLevel == "" {
@@ -85,10 +90,16 @@ func main() {
 	// Initialize components
 	modelRegistry := model.NewRegistry()
 	modelRegistry.Load(cfg.Spec.Models)
+	modelRegistry.UpdateAliases(cfg.Spec.Aliases)
+	modelRegistry.UpdateRules(cfg.Spec.Rules)
 
 	providerMgr := provider.NewManager()
 	providerMgr.Load(cfg.Spec.Providers)
 
+	// Register the models that providers with discoverModels list, now and on their interval
+	providerMgr.StartDiscovery(modelRegistry)
+	defer providerMgr.StopDiscovery()
+
 	// Create dynamic auth service
 	authService := auth.NewService()
 	authService.UpdateKeys(cfg.Spec.APIKeys)
@@ -174,7 +185,24 @@ func main() {
 	}
 
 	tracker := metrics.NewTracker()
-	errorTracker := metrics.NewErrorTracker()
+
+	// Initialize circuit breakers, overriding the defaults from configuration
+	breakerConfig := metrics.DefaultBreakerConfig()
+	if cb := cfg.Spec.CircuitBreaker; cb != nil {
+		if d, err := time.ParseDuration(cb.Window); err == nil && d > 0 {
+			breakerConfig.Window = d
+		}
+		if cb.MinRequests > 0 {
+			breakerConfig.MinRequests = cb.MinRequests
+		}
+		if cb.ErrorRate > 0 {
+			breakerConfig.ErrorRate = cb.ErrorRate
+		}
+		if d, err := time.ParseDuration(cb.Cooldown); err == nil && d > 0 {
+			breakerConfig.Cooldown = d
+		}
+	}
+	errorTracker := metrics.NewErrorTracker(metrics.NewCircuitBreaker(breakerConfig))
 
 	// Initialize request logger if --log-file flag is provided
 	var reqLogger *requestlog.RequestLogger
@@ -188,12 +216,13 @@ func main() {
 		defer reqLogger.Close()
 	}
 
-	selector := router.NewSelector(modelRegistry, providerMgr, tracker, *tpsThreshold)
+	selector := router.NewSelector(modelRegistry, providerMgr, tracker, errorTracker.Breaker(), *tpsThreshold, *slowProviders == "last")
 	fallbackMgr := router.NewFallbackManager(selector)
 
 	// Initialize retry configuration (used only when retrying the same provider is enabled)
 	retryConfig := retry.DefaultConfig()
 	retrySameProvider := false
+	var streamCommit proxy.StreamCommitPolicy // Streams commit on their first content delta by default
 	if cfg.Spec.Retry != nil {
 		if cfg.Spec.Retry.MaxRetries > 0 {
 			retryConfig.MaxRetries = cfg.Spec.Retry.MaxRetries
@@ -213,9 +242,20 @@ func main() {
 		if cfg.Spec.Retry.BackoffMultiplier > 0 {
 			retryConfig.BackoffMultiplier = cfg.Spec.Retry.BackoffMultiplier
 		}
+		if cfg.Spec.Retry.MaxRetryAfter != "" {
+			if d, err := time.ParseDuration(cfg.Spec.Retry.MaxRetryAfter); err == nil {
+				retryConfig.MaxRetryAfter = d
+			}
+		}
 		if cfg.Spec.Retry.RetrySameProvider {
 			retrySameProvider = true
 		}
+		streamCommit.OnResponse = cfg.Spec.Retry.StreamCommit == "response"
+		if cfg.Spec.Retry.StreamCommitAfter != "" {
+			if d, err := time.ParseDuration(cfg.Spec.Retry.StreamCommitAfter); err == nil {
+				streamCommit.After = d
+			}
+		}
 	}
 
 	// Start benchmark job
@@ -224,10 +264,10 @@ func main() {
 	defer benchmarker.Stop()
 
 	// Initialize handlers (needed for both modes)
-	proxyHandler := proxy.NewHandler(fallbackMgr, tracker, errorTracker, retryConfig, retrySameProvider, reqLogger, analyticsService)
+	proxyHandler := proxy.NewHandler(fallbackMgr, tracker, errorTracker, retryConfig, retrySameProvider, reqLogger, analyticsService, streamCommit)
 	modelsHandler := proxy.NewModelsHandler(modelRegistry)
 	healthHandler := proxy.NewHealthHandler(providerMgr, tracker, errorTracker)
-	countTokensHandler := proxy.NewCountTokensHandler(fallbackMgr)
+	countTokensHandler := proxy.NewCountTokensHandler(fallbackMgr, cfg.Spec.CountTokens == config.CountTokensLocal)
 
 	// Start HTTP server in background
 	srv := startHTTPServer(cfg, proxyHandler, modelsHandler, healthHandler, countTokensHandler, authService, oidcClient, sessionManager, dbRepo, tokenManager, analyticsService)
@@ -312,7 +352,7 @@ func setupAdminRoutes(r *gin.Engine, cfg *config.Config, oidcClient *auth.OIDCCl
 	authHandler := api.NewAuthHandler(oidcClient, sessionManager, dbRepo)
 	tokenHandler := api.NewTokenHandler(tokenManager, sessionManager, dbRepo)
 	analyticsHandler := api.NewAnalyticsHandler(analyticsService, sessionManager)
-	adminHandler := api.NewAdminHandler(analyticsService, sessionManager, dbRepo)
+	adminHandler := api.NewAdminHandler(analyticsService, sessionManager, tokenManager, dbRepo)
 	configHandler := api.NewConfigHandler(cfg, sessionManager, tokenManager)
 
 	// Auth flow endpoints (no auth required)
@@ -398,6 +438,7 @@ func setupAdminRoutes(r *gin.Engine, cfg *config.Config, oidcClient *auth.OIDCCl
 		apiAdminGroup.GET("/users/:id/analytics", adminHandler.HandleGetUserAnalytics)
 		apiAdminGroup.POST("/users/:id/promote", adminHandler.HandlePromoteUser)
 		apiAdminGroup.POST("/users/:id/demote", adminHandler.HandleDemoteUser)
+		apiAdminGroup.PUT("/tokens/:id/routing-overrides", adminHandler.HandleSetTokenRoutingOverrides)
 	}
 
 	logger.Info("Admin UI and authentication routes configured",
@@ -430,8 +471,10 @@ func startHTTPServer(cfg *config.Config, proxyHandler *proxy.Handler, modelsHand
 	apiGroup.Use(authService.Middleware())
 	{
 		apiGroup.POST("/messages", proxyHandler.HandleMessages)
+		apiGroup.POST("/chat/completions", proxyHandler.HandleChatCompletions)
 		apiGroup.POST("/messages/count_tokens", countTokensHandler.HandleCountTokens)
 		apiGroup.GET("/models", modelsHandler.HandleListModels)
+		apiGroup.GET("/models/*id", modelsHandler.HandleGetModel)
 	}
 
 	// Setup HTTP server
@@ -4,7 +4,6 @@ import (
 	"anthropic-proxy/config"
 	"anthropic-proxy/logger"
 	"anthropic-proxy/provider"
-	"anthropic-proxy/transform"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -17,7 +16,7 @@ import (
 
 // RequestLogger interface to avoid circular dependency
 type RequestLogger interface {
-	LogRequest(provider, model, method, path string, headers map[string]string, body []byte, attemptNumber int, isStreaming bool) error
+	LogRequest(provider, model, method, path string, headers map[string]string, body []byte, attemptNumber int, isStreaming bool, rule string) error
 	LogResponse(provider, model string, statusCode int, headers map[string]string, body []byte, duration time.Duration, tokenCount, attemptNumber int, success bool, errorMsg string, isStreaming bool) error
 }
 
@@ -198,14 +197,8 @@ func (b *Benchmarker) benchmarkProviderModel(prov *provider.Provider, modelName
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Determine the actual API path based on provider type
-	apiPath := "/v1/messages"
-	if prov.Type == transform.ProviderTypeOpenAI {
-		apiPath = "/v1/chat/completions"
-	}
-
-	// Build full URL
-	fullURL := prov.Endpoint + apiPath
+	// Build full URL for logging; the adapter decides the actual API path
+	fullURL := prov.Client.RequestURL("/v1/messages", bodyBytes)
 
 	logger.Info("Benchmark request starting",
 		"provider", prov.Name,
@@ -216,7 +209,7 @@ func (b *Benchmarker) benchmarkProviderModel(prov *provider.Provider, modelName
 
 	// Log request to file if logger is enabled
 	if b.requestLogger != nil {
-		b.requestLogger.LogRequest(prov.Name, modelName, "POST", fullURL, nil, bodyBytes, 0, true)
+		b.requestLogger.LogRequest(prov.Name, modelName, "POST", fullURL, nil, bodyBytes, 0, true, "")
 	}
 
 	startTime := time.Now()
@@ -275,8 +268,8 @@ func (b *Benchmarker) benchmarkProviderModel(prov *provider.Provider, modelName
 		"statusCode", resp.StatusCode,
 		"duration", duration.String())
 
-	// Read response body first for better error handling
-	responseBody, err := io.ReadAll(resp.Body)
+	// Read the response body, decoded into an Anthropic SSE stream by the provider's adapter
+	responseBody, err := io.ReadAll(prov.Client.DecodeStream(resp.Body, modelName))
 	if err != nil {
 		logger.Error("Error reading benchmark response body",
 			"provider", prov.Name,
@@ -300,56 +293,7 @@ func (b *Benchmarker) benchmarkProviderModel(prov *provider.Provider, modelName
 		"model", modelName,
 		"bodyLength", len(responseBody))
 
-	// Convert OpenAI response to Anthropic format if needed
 	finalResponseBody := responseBody
-	if prov.Type == transform.ProviderTypeOpenAI {
-		logger.Info("Converting OpenAI response to Anthropic format",
-			"provider", prov.Name,
-			"model", modelName)
-
-		// For OpenAI providers, convert the response format
-		convertedBody, err := convertOpenAIStreamToAnthropic(responseBody, modelName)
-		if err != nil {
-			logger.Error("Error converting OpenAI stream response",
-				"provider", prov.Name,
-				"model", modelName,
-				"error", err.Error(),
-				"rawResponseLen", len(responseBody))
-
-			// Try to extract tokens from raw OpenAI response as fallback
-			logger.Info("Attempting fallback token extraction from raw OpenAI response",
-				"provider", prov.Name,
-				"model", modelName)
-
-			tokens, _ := parseOpenAIStreamingResponse(string(responseBody))
-			if tokens > 0 {
-				logger.Info("Fallback token extraction successful",
-					"provider", prov.Name,
-					"model", modelName,
-					"tokens", tokens)
-				b.tracker.RecordRequest(prov.Name, modelName, tokens, duration)
-				tps := b.tracker.GetTPS(prov.Name, modelName)
-				result.Success = true
-				result.TPS = tps
-				result.Tokens = tokens
-				result.DurationS = duration.Seconds()
-			} else {
-				logger.Error("Fallback token extraction failed",
-					"provider", prov.Name,
-					"model", modelName)
-				result.ErrorMessage = fmt.Sprintf("conversion error: %v", err)
-			}
-			b.storeResult(result)
-			return
-		}
-
-		logger.Info("OpenAI response converted successfully",
-			"provider", prov.Name,
-			"model", modelName,
-			"convertedLength", len(convertedBody))
-
-		finalResponseBody = convertedBody
-	}
 
 	// Parse streaming response to get token count
 	responseStr := string(finalResponseBody)
@@ -536,154 +480,3 @@ func (b *Benchmarker) RunManualBenchmark() {
 	// Run in goroutine to avoid blocking
 	go b.runBenchmark()
 }
-
-// convertOpenAIStreamToAnthropic converts OpenAI streaming response to Anthropic format
-func convertOpenAIStreamToAnthropic(openaiStream []byte, model string) ([]byte, error) {
-	responseStr := string(openaiStream)
-	lines := strings.Split(responseStr, "\n")
-
-	var anthropicLines []string
-	messageStarted := false
-	contentBlockStarted := false
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "data: ") {
-			dataJSON := strings.TrimPrefix(line, "data: ")
-
-			if dataJSON == "[DONE]" {
-				// Add message_stop
-				stopEvent := map[string]interface{}{"type": "message_stop"}
-				stopJSON, _ := json.Marshal(stopEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(stopJSON))
-				continue
-			}
-
-			var chunk transform.OpenAIStreamChunk
-			if err := json.Unmarshal([]byte(dataJSON), &chunk); err != nil {
-				continue
-			}
-
-			if len(chunk.Choices) == 0 {
-				continue
-			}
-
-			choice := chunk.Choices[0]
-
-			// Handle first chunk - send message_start and content_block_start
-			if choice.Delta.Role != "" && !messageStarted {
-				messageStarted = true
-				startEvent := map[string]interface{}{
-					"type": "message_start",
-					"message": map[string]interface{}{
-						"id":    chunk.ID,
-						"type":  "message",
-						"role":  "assistant",
-						"model": model,
-						"usage": map[string]int{
-							"input_tokens":  0,
-							"output_tokens": 0,
-						},
-					},
-				}
-				startJSON, _ := json.Marshal(startEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(startJSON))
-			}
-
-			textSegments := transform.ExtractOpenAIText(choice.Delta.Content)
-			textDelta := strings.Join(textSegments, "")
-
-			if textDelta != "" && !contentBlockStarted {
-				contentBlockStarted = true
-				blockStartEvent := map[string]interface{}{
-					"type":  "content_block_start",
-					"index": 0,
-					"content_block": map[string]interface{}{
-						"type": "text",
-						"text": "",
-					},
-				}
-				blockJSON, _ := json.Marshal(blockStartEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(blockJSON))
-			}
-
-			// Handle content delta
-			if textDelta != "" {
-				deltaEvent := map[string]interface{}{
-					"type":  "content_block_delta",
-					"index": 0,
-					"delta": map[string]interface{}{
-						"type": "text_delta",
-						"text": textDelta,
-					},
-				}
-				deltaJSON, _ := json.Marshal(deltaEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(deltaJSON))
-			}
-
-			// Handle finish
-			if choice.FinishReason != nil && *choice.FinishReason != "" {
-				// content_block_stop
-				blockStopEvent := map[string]interface{}{
-					"type":  "content_block_stop",
-					"index": 0,
-				}
-				blockStopJSON, _ := json.Marshal(blockStopEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(blockStopJSON))
-
-				// message_delta
-				stopReason := "end_turn"
-				if *choice.FinishReason == "length" {
-					stopReason = "max_tokens"
-				}
-				deltaEvent := map[string]interface{}{
-					"type": "message_delta",
-					"delta": map[string]interface{}{
-						"stop_reason": stopReason,
-					},
-					"usage": map[string]int{
-						"output_tokens": 0,
-					},
-				}
-				deltaJSON, _ := json.Marshal(deltaEvent)
-				anthropicLines = append(anthropicLines, "data: "+string(deltaJSON))
-			}
-		}
-	}
-
-	return []byte(strings.Join(anthropicLines, "\n")), nil
-}
-
-// parseOpenAIStreamingResponse extracts tokens from OpenAI streaming format
-func parseOpenAIStreamingResponse(sseData string) (outputTokens int, inputTokens int) {
-	lines := strings.Split(sseData, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "data: ") {
-			dataJSON := strings.TrimPrefix(line, "data: ")
-			if dataJSON == "[DONE]" {
-				continue
-			}
-
-			var chunk transform.OpenAIStreamChunk
-			if err := json.Unmarshal([]byte(dataJSON), &chunk); err == nil {
-				if len(chunk.Choices) > 0 {
-					choice := chunk.Choices[0]
-					textParts := transform.ExtractOpenAIText(choice.Delta.Content)
-					textDelta := strings.Join(textParts, "")
-					if textDelta != "" {
-						// Rough estimate: ~4 chars per token
-						textTokens := len(textDelta) / 4
-						if textTokens == 0 && len(textDelta) > 0 {
-							textTokens = 1
-						}
-						outputTokens += textTokens
-					}
-				}
-			}
-		}
-	}
-
-	return outputTokens, inputTokens
-}
@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"anthropic-proxy/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a provider/model circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Requests are skipped until the cooldown ends
+	BreakerHalfOpen BreakerState = "half_open" // A single probe request decides whether to close again
+)
+
+// BreakerConfig controls when circuit breakers open and how they recover
+type BreakerConfig struct {
+	Window      time.Duration // Rolling window outcomes are counted over
+	MinRequests int           // Requests needed in the window before the breaker can open
+	ErrorRate   float64       // Error rate in the window that opens the breaker
+	Cooldown    time.Duration // How long an open breaker waits before letting a probe through
+}
+
+// DefaultBreakerConfig returns the default circuit breaker configuration
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:      5 * time.Minute,
+		MinRequests: 10,
+		ErrorRate:   0.5,
+		Cooldown:    30 * time.Second,
+	}
+}
+
+// breakerBuckets is the number of slices the rolling window is divided into
+const breakerBuckets = 10
+
+// BreakerStatus is a snapshot of one circuit breaker
+type BreakerStatus struct {
+	ProviderName string
+	ModelName    string
+	State        BreakerState
+	Requests     int       // Requests in the current window
+	Errors       int       // Failed requests in the current window
+	OpenedAt     time.Time // When the breaker last opened; zero if it never did
+}
+
+// CircuitBreaker keeps a circuit breaker per provider/model combination. A breaker
+// opens when the error rate over a rolling window crosses the threshold, skips the
+// combination for the cooldown, then lets a single probe request decide whether to
+// close again or stay open for another cooldown.
+type CircuitBreaker struct {
+	config   BreakerConfig
+	breakers map[string]*breaker
+	mu       sync.Mutex
+}
+
+// breaker is the circuit breaker for one provider/model combination
+type breaker struct {
+	providerName string
+	modelName    string
+	state        BreakerState
+	buckets      [breakerBuckets]breakerBucket
+	openedAt     time.Time
+	probeStarted time.Time // When the half-open probe was let through; zero if none is in flight
+}
+
+// breakerBucket counts outcomes for one slice of the rolling window
+type breakerBucket struct {
+	start     time.Time
+	successes int
+	errors    int
+}
+
+// NewCircuitBreaker creates a circuit breaker registry
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config:   config,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Allow reports whether a request may be sent to a provider/model. An open breaker
+// whose cooldown has passed moves to half-open and lets this request through as the probe.
+func (cb *CircuitBreaker) Allow(providerName, modelName string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[makeModelKey(providerName, modelName)]
+	if !exists {
+		return true
+	}
+
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < cb.config.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probeStarted = now
+		logger.Info("Circuit breaker half-open, sending probe",
+			"provider", providerName,
+			"model", modelName)
+		return true
+
+	case BreakerHalfOpen:
+		// A probe that never reported back (cancelled or abandoned) is replaced after a cooldown
+		if !b.probeStarted.IsZero() && now.Sub(b.probeStarted) < cb.config.Cooldown {
+			return false
+		}
+		b.probeStarted = now
+		return true
+
+	default:
+		return true
+	}
+}
+
+// State returns the state of a provider/model breaker. An open breaker whose cooldown
+// has passed reports half-open, since its next request will be let through.
+func (cb *CircuitBreaker) State(providerName, modelName string) BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, exists := cb.breakers[makeModelKey(providerName, modelName)]
+	if !exists {
+		return BreakerClosed
+	}
+	return cb.effectiveState(b, time.Now())
+}
+
+// RecordSuccess records a successful request, closing a half-open breaker
+func (cb *CircuitBreaker) RecordSuccess(providerName, modelName string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	b := cb.ensureBreaker(providerName, modelName)
+	cb.bucket(b, now).successes++
+
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerClosed
+		b.probeStarted = time.Time{}
+		b.buckets = [breakerBuckets]breakerBucket{}
+		logger.Info("Circuit breaker closed",
+			"provider", providerName,
+			"model", modelName)
+	}
+}
+
+// RecordFailure records a failed request, opening the breaker when the threshold is crossed
+func (cb *CircuitBreaker) RecordFailure(providerName, modelName string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	b := cb.ensureBreaker(providerName, modelName)
+	cb.bucket(b, now).errors++
+
+	switch b.state {
+	case BreakerHalfOpen:
+		// The probe failed; wait another cooldown
+		cb.open(b, now)
+
+	case BreakerClosed:
+		requests, errors := cb.windowCounts(b, now)
+		if requests >= cb.config.MinRequests && requests > 0 &&
+			float64(errors)/float64(requests) >= cb.config.ErrorRate {
+			cb.open(b, now)
+		}
+	}
+}
+
+// ReleaseProbe gives up a half-open probe that was cancelled before it had an outcome,
+// so the next request can probe right away instead of waiting for it to be abandoned
+func (cb *CircuitBreaker) ReleaseProbe(providerName, modelName string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if b, exists := cb.breakers[makeModelKey(providerName, modelName)]; exists && b.state == BreakerHalfOpen {
+		b.probeStarted = time.Time{}
+	}
+}
+
+// GetAll returns the status of every breaker, sorted by provider and model
+func (cb *CircuitBreaker) GetAll() []BreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]BreakerStatus, 0, len(cb.breakers))
+	for _, b := range cb.breakers {
+		requests, errors := cb.windowCounts(b, now)
+		statuses = append(statuses, BreakerStatus{
+			ProviderName: b.providerName,
+			ModelName:    b.modelName,
+			State:        cb.effectiveState(b, now),
+			Requests:     requests,
+			Errors:       errors,
+			OpenedAt:     b.openedAt,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].ProviderName != statuses[j].ProviderName {
+			return statuses[i].ProviderName < statuses[j].ProviderName
+		}
+		return statuses[i].ModelName < statuses[j].ModelName
+	})
+	return statuses
+}
+
+// open trips a breaker; callers hold the lock
+func (cb *CircuitBreaker) open(b *breaker, now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.probeStarted = time.Time{}
+	logger.Warn("Circuit breaker opened",
+		"provider", b.providerName,
+		"model", b.modelName,
+		"cooldown", cb.config.Cooldown.String())
+}
+
+// effectiveState returns the state callers should see; callers hold the lock
+func (cb *CircuitBreaker) effectiveState(b *breaker, now time.Time) BreakerState {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= cb.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// bucket returns the window bucket for now, resetting it if it holds an older slice; callers hold the lock
+func (cb *CircuitBreaker) bucket(b *breaker, now time.Time) *breakerBucket {
+	width := cb.bucketWidth()
+	start := now.Truncate(width)
+	bucket := &b.buckets[(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// windowCounts sums the requests and errors inside the rolling window; callers hold the lock
+func (cb *CircuitBreaker) windowCounts(b *breaker, now time.Time) (int, int) {
+	cutoff := now.Add(-cb.config.Window)
+	requests, errors := 0, 0
+	for _, bucket := range b.buckets {
+		if bucket.start.After(cutoff) {
+			requests += bucket.successes + bucket.errors
+			errors += bucket.errors
+		}
+	}
+	return requests, errors
+}
+
+func (cb *CircuitBreaker) bucketWidth() time.Duration {
+	width := cb.config.Window / breakerBuckets
+	if width <= 0 {
+		return time.Second
+	}
+	return width
+}
+
+func (cb *CircuitBreaker) ensureBreaker(providerName, modelName string) *breaker {
+	key := makeModelKey(providerName, modelName)
+	if b, exists := cb.breakers[key]; exists {
+		return b
+	}
+
+	b := &breaker{providerName: providerName, modelName: modelName, state: BreakerClosed}
+	cb.breakers[key] = b
+	return b
+}
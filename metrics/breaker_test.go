@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"anthropic-proxy/logger"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitQuiet("error")
+	os.Exit(m.Run())
+}
+
+// openBreaker returns a breaker for provider/model that has opened and whose cooldown has passed
+func openBreaker(t *testing.T) *CircuitBreaker {
+	t.Helper()
+
+	cb := NewCircuitBreaker(BreakerConfig{Window: time.Minute, MinRequests: 1, ErrorRate: 0.5, Cooldown: time.Hour})
+	cb.RecordFailure("provider", "model")
+	if state := cb.State("provider", "model"); state != BreakerOpen {
+		t.Fatalf("state after failure = %s, want %s", state, BreakerOpen)
+	}
+	cb.breakers[makeModelKey("provider", "model")].openedAt = time.Now().Add(-2 * time.Hour)
+	return cb
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcome   func(cb *CircuitBreaker)
+		wantState BreakerState
+		wantAllow bool // Whether the next request is let through
+	}{
+		{
+			name:      "probe in flight",
+			outcome:   func(cb *CircuitBreaker) {},
+			wantState: BreakerHalfOpen,
+			wantAllow: false,
+		},
+		{
+			name:      "probe succeeded",
+			outcome:   func(cb *CircuitBreaker) { cb.RecordSuccess("provider", "model") },
+			wantState: BreakerClosed,
+			wantAllow: true,
+		},
+		{
+			name:      "probe failed",
+			outcome:   func(cb *CircuitBreaker) { cb.RecordFailure("provider", "model") },
+			wantState: BreakerOpen,
+			wantAllow: false,
+		},
+		{
+			name:      "probe released",
+			outcome:   func(cb *CircuitBreaker) { cb.ReleaseProbe("provider", "model") },
+			wantState: BreakerHalfOpen,
+			wantAllow: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := openBreaker(t)
+			if !cb.Allow("provider", "model") {
+				t.Fatal("probe was not let through after the cooldown")
+			}
+
+			tt.outcome(cb)
+			if state := cb.State("provider", "model"); state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+			if allow := cb.Allow("provider", "model"); allow != tt.wantAllow {
+				t.Errorf("next request allowed = %v, want %v", allow, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerReleaseProbeLeavesClosedBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(DefaultBreakerConfig())
+	cb.RecordSuccess("provider", "model")
+	cb.ReleaseProbe("provider", "model")
+	if state := cb.State("provider", "model"); state != BreakerClosed {
+		t.Errorf("state = %s, want %s", state, BreakerClosed)
+	}
+}
@@ -18,6 +18,12 @@ type MetricData struct {
 	TPS          float64  // Current average tokens per second
 	Samples      []Sample // Recent samples for averaging
 	MaxSamples   int      // Maximum number of samples to keep
+
+	TTFT        time.Duration   // Average time to first token over recent streams
+	TTFTSamples []time.Duration // Recent time to first token measurements
+	TTFTEWMA    time.Duration   // Exponentially weighted moving average of time to first token
+	HedgeWins   int             // Hedged races this provider-model won
+	HedgeLosses int             // Hedged races this provider-model lost
 }
 
 // Sample represents a single TPS measurement
@@ -28,6 +34,9 @@ type Sample struct {
 	Timestamp time.Time
 }
 
+// ttftEWMAAlpha is the weight of the newest sample in the time to first token moving average
+const ttftEWMAAlpha = 0.3
+
 // NewCache creates a new metrics cache
 func NewCache() *Cache {
 	return &Cache{
@@ -67,17 +76,7 @@ func (c *Cache) UpdateTPS(providerName, modelName string, tokens int, durationS
 		Timestamp: time.Now(),
 	}
 
-	// Get or create metric data
-	if _, exists := c.data[key]; !exists {
-		c.data[key] = &MetricData{
-			ProviderName: providerName,
-			ModelName:    modelName,
-			Samples:      []Sample{},
-			MaxSamples:   5, // Keep last 5 samples
-		}
-	}
-
-	data := c.data[key]
+	data := c.ensureEntry(key, providerName, modelName)
 
 	// Add sample
 	data.Samples = append(data.Samples, sample)
@@ -91,6 +90,78 @@ func (c *Cache) UpdateTPS(providerName, modelName string, tokens int, durationS
 	data.TPS = calculateAverageTPS(data.Samples)
 }
 
+// UpdateTTFT records a time to first token sample and recalculates the average
+func (c *Cache) UpdateTTFT(providerName, modelName string, ttft time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.ensureEntry(makeKey(providerName, modelName), providerName, modelName)
+	data.TTFTSamples = append(data.TTFTSamples, ttft)
+	if len(data.TTFTSamples) > data.MaxSamples {
+		data.TTFTSamples = data.TTFTSamples[len(data.TTFTSamples)-data.MaxSamples:]
+	}
+
+	var sum time.Duration
+	for _, sample := range data.TTFTSamples {
+		sum += sample
+	}
+	data.TTFT = sum / time.Duration(len(data.TTFTSamples))
+
+	if data.TTFTEWMA == 0 {
+		data.TTFTEWMA = ttft
+	} else {
+		data.TTFTEWMA = time.Duration(ttftEWMAAlpha*float64(ttft) + (1-ttftEWMAAlpha)*float64(data.TTFTEWMA))
+	}
+}
+
+// GetTTFT returns the average time to first token for a provider-model combination
+func (c *Cache) GetTTFT(providerName, modelName string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if data, exists := c.data[makeKey(providerName, modelName)]; exists {
+		return data.TTFT
+	}
+	return 0
+}
+
+// GetTTFTEWMA returns the moving average of time to first token for a provider-model combination
+func (c *Cache) GetTTFTEWMA(providerName, modelName string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if data, exists := c.data[makeKey(providerName, modelName)]; exists {
+		return data.TTFTEWMA
+	}
+	return 0
+}
+
+// RecordHedge records the result of a hedged race for a provider-model combination
+func (c *Cache) RecordHedge(providerName, modelName string, won bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.ensureEntry(makeKey(providerName, modelName), providerName, modelName)
+	if won {
+		data.HedgeWins++
+	} else {
+		data.HedgeLosses++
+	}
+}
+
+// ensureEntry returns the metric data for a key, creating it if needed; callers hold the lock
+func (c *Cache) ensureEntry(key, providerName, modelName string) *MetricData {
+	if _, exists := c.data[key]; !exists {
+		c.data[key] = &MetricData{
+			ProviderName: providerName,
+			ModelName:    modelName,
+			Samples:      []Sample{},
+			MaxSamples:   5, // Keep last 5 samples
+		}
+	}
+	return c.data[key]
+}
+
 // GetAll returns all metric data
 func (c *Cache) GetAll() map[string]*MetricData {
 	c.mu.RLock()
@@ -105,6 +176,10 @@ func (c *Cache) GetAll() map[string]*MetricData {
 			TPS:          data.TPS,
 			Samples:      append([]Sample{}, data.Samples...),
 			MaxSamples:   data.MaxSamples,
+			TTFT:         data.TTFT,
+			TTFTSamples:  append([]time.Duration{}, data.TTFTSamples...),
+			HedgeWins:    data.HedgeWins,
+			HedgeLosses:  data.HedgeLosses,
 		}
 	}
 	return result
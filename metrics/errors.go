@@ -5,10 +5,12 @@ import (
     "time"
 )
 
-// ErrorTracker tracks error rates per provider/model combination
+// ErrorTracker tracks error rates per provider/model combination and feeds
+// every outcome to the circuit breaker
 type ErrorTracker struct {
     providerData map[string]*ErrorData
     modelData    map[string]*ErrorData
+    breaker      *CircuitBreaker
     mu           sync.RWMutex
 }
 
@@ -24,14 +26,20 @@ type ErrorData struct {
     ErrorRate       float64
 }
 
-// NewErrorTracker creates a new error tracker
-func NewErrorTracker() *ErrorTracker {
+// NewErrorTracker creates a new error tracker reporting to the given circuit breaker
+func NewErrorTracker(breaker *CircuitBreaker) *ErrorTracker {
     return &ErrorTracker{
         providerData: make(map[string]*ErrorData),
         modelData:    make(map[string]*ErrorData),
+        breaker:      breaker,
     }
 }
 
+// Breaker returns the circuit breaker fed by this tracker
+func (e *ErrorTracker) Breaker() *CircuitBreaker {
+    return e.breaker
+}
+
 // RecordSuccess records a successful request
 func (e *ErrorTracker) RecordSuccess(providerName, modelName string) {
     e.mu.Lock()
@@ -47,10 +55,11 @@ func (e *ErrorTracker) RecordSuccess(providerName, modelName string) {
         modelEntry.TotalRequests++
         modelEntry.SuccessCount++
         modelEntry.ErrorRate = calculateErrorRate(modelEntry.SuccessCount, modelEntry.ErrorCount)
+        e.breaker.RecordSuccess(providerName, modelName)
     }
 }
 
-// RecordError records a failed request
+// RecordError records a failed request; statusCode is 0 for network errors and timeouts
 func (e *ErrorTracker) RecordError(providerName, modelName string, statusCode int) {
     e.mu.Lock()
     defer e.mu.Unlock()
@@ -69,9 +78,20 @@ func (e *ErrorTracker) RecordError(providerName, modelName string, statusCode in
         modelEntry.LastError = time.Now()
         modelEntry.LastErrorStatus = statusCode
         modelEntry.ErrorRate = calculateErrorRate(modelEntry.SuccessCount, modelEntry.ErrorCount)
+        if tripsBreaker(statusCode) {
+            e.breaker.RecordFailure(providerName, modelName)
+        }
     }
 }
 
+// tripsBreaker reports whether a failure says something about the provider's health:
+// network errors and timeouts (status 0) and server errors including 529 overloaded.
+// Client errors such as a malformed request and rate limits, which have their own
+// cooldown, are left out so one client cannot open the breaker for everyone.
+func tripsBreaker(statusCode int) bool {
+    return statusCode == 0 || statusCode >= 500
+}
+
 // GetErrorRate returns the aggregated error rate for a provider
 func (e *ErrorTracker) GetErrorRate(providerName string) float64 {
     e.mu.RLock()
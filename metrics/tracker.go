@@ -1,18 +1,23 @@
 package metrics
 
 import (
+	"sync"
 	"time"
 )
 
-// Tracker tracks TPS metrics for requests
+// Tracker tracks TPS metrics for requests and rate limit cooldowns
 type Tracker struct {
 	cache *Cache
+
+	cooldowns   map[string]time.Time // Provider/model rate limit resets
+	cooldownsMu sync.RWMutex
 }
 
 // NewTracker creates a new metrics tracker
 func NewTracker() *Tracker {
 	return &Tracker{
-		cache: NewCache(),
+		cache:     NewCache(),
+		cooldowns: make(map[string]time.Time),
 	}
 }
 
@@ -32,6 +37,26 @@ func (t *Tracker) GetTPS(providerName, modelName string) float64 {
 	return t.cache.GetTPS(providerName, modelName)
 }
 
+// RecordFirstToken records how long a stream took to produce its first token
+func (t *Tracker) RecordFirstToken(providerName, modelName string, ttft time.Duration) {
+	t.cache.UpdateTTFT(providerName, modelName, ttft)
+}
+
+// GetTTFT returns the average time to first token for a provider-model combination, or 0 if unknown
+func (t *Tracker) GetTTFT(providerName, modelName string) time.Duration {
+	return t.cache.GetTTFT(providerName, modelName)
+}
+
+// GetTTFTEWMA returns the moving average of time to first token, weighted towards recent streams, or 0 if unknown
+func (t *Tracker) GetTTFTEWMA(providerName, modelName string) time.Duration {
+	return t.cache.GetTTFTEWMA(providerName, modelName)
+}
+
+// RecordHedge records whether a provider-model won or lost a hedged race
+func (t *Tracker) RecordHedge(providerName, modelName string, won bool) {
+	t.cache.RecordHedge(providerName, modelName, won)
+}
+
 // MeetsThreshold checks if a provider-model meets the minimum TPS threshold
 func (t *Tracker) MeetsThreshold(providerName, modelName string, threshold float64) bool {
 	tps := t.GetTPS(providerName, modelName)
@@ -44,6 +69,29 @@ func (t *Tracker) MeetsThreshold(providerName, modelName string, threshold float
 	return tps >= threshold
 }
 
+// SetCooldown marks a provider-model as rate limited until the given time
+func (t *Tracker) SetCooldown(providerName, modelName string, until time.Time) {
+	t.cooldownsMu.Lock()
+	defer t.cooldownsMu.Unlock()
+
+	key := makeKey(providerName, modelName)
+	if until.After(t.cooldowns[key]) {
+		t.cooldowns[key] = until
+	}
+}
+
+// CooldownUntil returns when a provider-model's rate limit resets; zero if it is not cooling down
+func (t *Tracker) CooldownUntil(providerName, modelName string) time.Time {
+	t.cooldownsMu.RLock()
+	defer t.cooldownsMu.RUnlock()
+
+	until := t.cooldowns[makeKey(providerName, modelName)]
+	if !until.After(time.Now()) {
+		return time.Time{}
+	}
+	return until
+}
+
 // GetAllMetrics returns all tracked metrics
 func (t *Tracker) GetAllMetrics() map[string]*MetricData {
 	return t.cache.GetAll()
@@ -4,19 +4,27 @@ import (
 	"anthropic-proxy/config"
 	"anthropic-proxy/logger"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 )
 
 // Registry manages model configurations
 type Registry struct {
-	models []*config.Model // slice of models to support duplicates with different aliases
-	mu     sync.RWMutex
+	models     []*config.Model            // Configured then discovered models; duplicates with different aliases are allowed
+	configured []*config.Model            // Models from the configuration file
+	discovered map[string][]*config.Model // Models listed by providers, keyed by provider name
+	aliases    map[string]config.Alias
+	rules      []config.Rule
+	mu         sync.RWMutex
 }
 
 // NewRegistry creates a new model registry
 func NewRegistry() *Registry {
 	return &Registry{
-		models: make([]*config.Model, 0),
+		models:     make([]*config.Model, 0),
+		discovered: make(map[string][]*config.Model),
+		aliases:    make(map[string]config.Alias),
 	}
 }
 
@@ -27,12 +35,107 @@ func (r *Registry) Load(models []config.Model) {
 
 	for i := range models {
 		model := &models[i]
-		r.models = append(r.models, model)
+		r.configured = append(r.configured, model)
 		logger.Debug("Loaded model",
 			"name", model.Name,
 			"alias", model.Alias,
 			"provider", model.Provider)
 	}
+	r.rebuild()
+}
+
+// SetDiscoveredModels replaces the models discovered on a provider; nil removes them
+func (r *Registry) SetDiscoveredModels(providerName string, models []config.Model) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(models) == 0 {
+		delete(r.discovered, providerName)
+	} else {
+		discovered := make([]*config.Model, 0, len(models))
+		for i := range models {
+			discovered = append(discovered, &models[i])
+		}
+		r.discovered[providerName] = discovered
+	}
+	r.rebuild()
+
+	logger.Info("Discovered models updated",
+		"provider", providerName,
+		"models", len(models),
+		"total", len(r.models))
+}
+
+// rebuild combines configured and discovered models; a configured model wins over a
+// discovered one with the same name on the same provider. Callers hold the lock.
+func (r *Registry) rebuild() {
+	configured := make(map[string]bool, len(r.configured))
+	for _, model := range r.configured {
+		configured[model.Provider+"::"+model.Name] = true
+	}
+
+	providers := make([]string, 0, len(r.discovered))
+	for name := range r.discovered {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	models := append(make([]*config.Model, 0, len(r.configured)), r.configured...)
+	for _, name := range providers {
+		for _, model := range r.discovered[name] {
+			if !configured[model.Provider+"::"+model.Name] {
+				models = append(models, model)
+			}
+		}
+	}
+	r.models = models
+}
+
+// UpdateAliases replaces the alias routing settings
+func (r *Registry) UpdateAliases(aliases map[string]config.Alias) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.aliases = make(map[string]config.Alias, len(aliases))
+	for name, alias := range aliases {
+		r.aliases[name] = alias
+	}
+}
+
+// UpdateRules replaces the routing rules
+func (r *Registry) UpdateRules(rules []config.Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+}
+
+// GetRules returns the routing rules in evaluation order
+func (r *Registry) GetRules() []config.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rules
+}
+
+// GetAlias returns the routing settings for a requested model name. An exact key wins;
+// otherwise the longest alias pattern matching the name is used.
+func (r *Registry) GetAlias(requestedName string) (string, config.Alias, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if alias, exists := r.aliases[requestedName]; exists {
+		return requestedName, alias, true
+	}
+
+	bestName := ""
+	for name := range r.aliases {
+		if MatchAlias(name, requestedName) && (len(name) > len(bestName) || (len(name) == len(bestName) && name < bestName)) {
+			bestName = name
+		}
+	}
+	if bestName == "" {
+		return "", config.Alias{}, false
+	}
+	return bestName, r.aliases[bestName], true
 }
 
 // GetByName returns a model by its exact name (returns first match)
@@ -89,6 +192,26 @@ func (r *Registry) GetAll() []*config.Model {
 	return models
 }
 
+// ModelIDs returns the names clients can request, in registry order: every model name
+// and every alias without a wildcard, each once
+func (r *Registry) ModelIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool, len(r.models))
+	var ids []string
+	for _, model := range r.models {
+		for _, id := range []string{model.Name, model.Alias} {
+			if id == "" || seen[id] || strings.Contains(id, "*") {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // Size returns the number of models in the registry
 func (r *Registry) Size() int {
 	r.mu.RLock()
@@ -108,7 +231,7 @@ func (r *Registry) UpdateModels(newModels []config.Model) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Create a new slice of models
+	// Create a new slice of configured models
 	updatedModels := make([]*config.Model, 0, len(newModels))
 
 	for i := range newModels {
@@ -120,8 +243,9 @@ func (r *Registry) UpdateModels(newModels []config.Model) {
 			"provider", model.Provider)
 	}
 
-	// Replace the entire models slice atomically
-	r.models = updatedModels
+	// Replace the configured models atomically, keeping the discovered ones
+	r.configured = updatedModels
+	r.rebuild()
 
 	logger.Info("Model registry updated", "total", len(r.models))
 }
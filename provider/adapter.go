@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Adapter translates between the Anthropic Messages API used inside the proxy and
+// one provider API. Everything that differs between backends lives here, so the
+// proxy and the benchmarker share a single code path for every provider type.
+type Adapter interface {
+	// EncodeRequest converts an Anthropic request sent to path (/v1/messages or
+	// /v1/messages/count_tokens) into the provider request body and path
+	EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error)
+
+	// Authenticate sets the authentication headers on a request that is ready to send
+	Authenticate(ctx context.Context, req *http.Request, body []byte) error
+
+	// DecodeResponse converts a successful non-streaming response body to Anthropic format
+	DecodeResponse(body []byte, model string) ([]byte, error)
+
+	// DecodeStream converts a successful streaming response body to an Anthropic SSE stream
+	DecodeStream(body io.ReadCloser, model string) io.ReadCloser
+}
+
+// AdapterSpec describes a provider type: how to build its adapter and how it is configured
+type AdapterSpec struct {
+	// New builds the adapter for one configured provider
+	New func(settings config.Provider, httpClient *http.Client) Adapter
+
+	// Validate checks type-specific settings; nil requires an endpoint and an API key
+	Validate func(settings config.Provider) error
+
+	// DefaultEndpoint derives the endpoint when none is configured; may be nil
+	DefaultEndpoint func(settings config.Provider) string
+
+	// DiscoverModels reports that the provider lists its models at GET /v1/models
+	// in the Anthropic or OpenAI format
+	DiscoverModels bool
+
+	// CountTokens reports that the provider serves /v1/messages/count_tokens; the
+	// proxy counts locally for providers that do not
+	CountTokens bool
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = make(map[string]AdapterSpec)
+)
+
+// RegisterAdapter makes a provider type available to configuration and the proxy
+func RegisterAdapter(providerType string, spec AdapterSpec) {
+	adaptersMu.Lock()
+	adapters[providerType] = spec
+	adaptersMu.Unlock()
+
+	config.RegisterProviderType(providerType, config.ProviderType{
+		Validate:        spec.Validate,
+		DefaultEndpoint: spec.DefaultEndpoint,
+		DiscoverModels:  spec.DiscoverModels,
+	})
+}
+
+// adapterSpec returns the spec registered for a provider's type
+func adapterSpec(settings config.Provider) (AdapterSpec, error) {
+	adaptersMu.RLock()
+	spec, exists := adapters[settings.GetType()]
+	adaptersMu.RUnlock()
+
+	if !exists {
+		return AdapterSpec{}, fmt.Errorf("unknown provider type %q", settings.GetType())
+	}
+	return spec, nil
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"io"
+	"net/http"
+)
+
+func init() {
+	RegisterAdapter(transform.ProviderTypeAnthropic, AdapterSpec{
+		New: func(settings config.Provider, _ *http.Client) Adapter {
+			return &anthropicAdapter{apiKey: settings.APIKey}
+		},
+		DiscoverModels: true,
+		CountTokens:    true,
+	})
+}
+
+// anthropicAdapter forwards requests to Anthropic-compatible APIs unchanged
+type anthropicAdapter struct {
+	anthropicResponses
+	apiKey string
+}
+
+func (a *anthropicAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	return body, path, nil
+}
+
+func (a *anthropicAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	req.Header.Set("x-api-key", a.apiKey)
+	// Keep the API version the client asked for, if any
+	if req.Header.Get("anthropic-version") == "" {
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	return nil
+}
+
+// anthropicResponses is embedded by adapters whose provider already answers in Anthropic format
+type anthropicResponses struct{}
+
+func (anthropicResponses) DecodeResponse(body []byte, model string) ([]byte, error) {
+	return body, nil
+}
+
+func (anthropicResponses) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	return body
+}
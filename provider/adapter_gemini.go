@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+func init() {
+	RegisterAdapter(transform.ProviderTypeGemini, AdapterSpec{
+		New: func(settings config.Provider, _ *http.Client) Adapter {
+			return &geminiAdapter{apiKey: settings.APIKey}
+		},
+	})
+}
+
+// geminiAdapter converts requests to the Gemini generateContent API
+type geminiAdapter struct {
+	apiKey string
+}
+
+func (a *geminiAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	if path != "/v1/messages" {
+		return nil, "", fmt.Errorf("%s is not supported by gemini providers", path)
+	}
+	// The model and streaming mode are part of the Gemini path
+	return transform.AnthropicToGeminiRequest(body)
+}
+
+func (a *geminiAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	req.Header.Set("x-goog-api-key", a.apiKey)
+	return nil
+}
+
+func (a *geminiAdapter) DecodeResponse(body []byte, model string) ([]byte, error) {
+	return transform.GeminiToAnthropicResponse(body, model)
+}
+
+func (a *geminiAdapter) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	return newConvertedStream(body, transform.NewGeminiStreamConverter(model))
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+func init() {
+	RegisterAdapter(transform.ProviderTypeOpenAI, AdapterSpec{
+		New: func(settings config.Provider, _ *http.Client) Adapter {
+			return &openAIAdapter{
+				apiKey: settings.APIKey,
+				options: transform.OpenAIRequestOptions{
+					ReasoningFormat: settings.ReasoningFormat,
+					OmitStreamUsage: settings.DisableStreamUsage,
+				},
+			}
+		},
+		DiscoverModels: true,
+	})
+	RegisterAdapter(transform.ProviderTypeResponses, AdapterSpec{
+		New: func(settings config.Provider, _ *http.Client) Adapter {
+			return &responsesAdapter{
+				apiKey:  settings.APIKey,
+				options: transform.OpenAIRequestOptions{ReasoningFormat: settings.ReasoningFormat},
+			}
+		},
+		DiscoverModels: true,
+	})
+}
+
+// openAIAdapter converts requests to the OpenAI chat completions API
+type openAIAdapter struct {
+	apiKey  string
+	options transform.OpenAIRequestOptions
+}
+
+func (a *openAIAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	// Chat completions has no token counting endpoint; sending it there would run a completion
+	if path != "/v1/messages" {
+		return nil, "", fmt.Errorf("%s is not supported by openai providers", path)
+	}
+	convertedBody, err := transform.AnthropicToOpenAIRequest(body, a.options)
+	// OpenAI uses /v1/chat/completions instead of /v1/messages
+	return convertedBody, "/v1/chat/completions", err
+}
+
+func (a *openAIAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	return nil
+}
+
+func (a *openAIAdapter) DecodeResponse(body []byte, model string) ([]byte, error) {
+	return transform.OpenAIToAnthropicResponse(body, model)
+}
+
+func (a *openAIAdapter) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	return newConvertedStream(body, transform.NewOpenAIStreamConverter(model))
+}
+
+// responsesAdapter converts requests to the OpenAI Responses API
+type responsesAdapter struct {
+	apiKey  string
+	options transform.OpenAIRequestOptions
+}
+
+func (a *responsesAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	if path != "/v1/messages" {
+		return nil, "", fmt.Errorf("%s is not supported by responses providers", path)
+	}
+	convertedBody, err := transform.AnthropicToResponsesRequest(body, a.options)
+	return convertedBody, "/v1/responses", err
+}
+
+func (a *responsesAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	return nil
+}
+
+func (a *responsesAdapter) DecodeResponse(body []byte, model string) ([]byte, error) {
+	return transform.ResponsesToAnthropicResponse(body, model)
+}
+
+func (a *responsesAdapter) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	return newConvertedStream(body, transform.NewResponsesStreamConverter(model))
+}
@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterAdapter(transform.ProviderTypeBedrock, AdapterSpec{
+		New: func(settings config.Provider, _ *http.Client) Adapter {
+			adapter := &bedrockAdapter{apiKey: settings.APIKey}
+			// A Bedrock API key is sent as a bearer token instead of signing with AWS credentials
+			if settings.APIKey == "" {
+				adapter.signer = newBedrockSigner(settings)
+			}
+			return adapter
+		},
+		Validate: func(settings config.Provider) error {
+			if settings.Region == "" {
+				return errors.New("region is required for bedrock providers")
+			}
+			if settings.APIKey == "" && (settings.AWSAccessKeyID == "") != (settings.AWSSecretAccessKey == "") {
+				return errors.New("awsAccessKeyId and awsSecretAccessKey must be set together")
+			}
+			return nil
+		},
+		DefaultEndpoint: func(settings config.Provider) string {
+			return "https://bedrock-runtime." + settings.Region + ".amazonaws.com"
+		},
+	})
+}
+
+// bedrockAdapter sends Anthropic requests to the Bedrock InvokeModel API
+type bedrockAdapter struct {
+	apiKey string
+	signer *bedrockSigner // nil when a Bedrock API key is used instead of SigV4
+}
+
+func (a *bedrockAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	// Bedrock has no Anthropic-compatible token counting endpoint
+	if path != "/v1/messages" {
+		return nil, "", fmt.Errorf("%s is not supported by bedrock providers", path)
+	}
+	return transform.AnthropicToBedrockRequest(body, headers["Anthropic-Beta"])
+}
+
+func (a *bedrockAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	if a.signer == nil {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+		return nil
+	}
+	return a.signer.sign(req, body, time.Now())
+}
+
+func (a *bedrockAdapter) DecodeResponse(body []byte, model string) ([]byte, error) {
+	return body, nil
+}
+
+func (a *bedrockAdapter) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	// Bedrock wraps each Anthropic event in its binary event-stream framing
+	return transform.NewBedrockEventStreamReader(body)
+}
+
+// bedrockSigner signs Bedrock requests with AWS Signature Version 4
+type bedrockSigner struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// newBedrockSigner builds a signer from the provider config, falling back to the standard AWS environment variables
+func newBedrockSigner(providerConfig config.Provider) *bedrockSigner {
+	signer := &bedrockSigner{
+		region:          providerConfig.Region,
+		accessKeyID:     providerConfig.AWSAccessKeyID,
+		secretAccessKey: providerConfig.AWSSecretAccessKey,
+		sessionToken:    providerConfig.AWSSessionToken,
+	}
+	if signer.accessKeyID == "" {
+		signer.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		signer.secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		signer.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	return signer
+}
+
+// sign adds the SigV4 Authorization header for the request body
+func (s *bedrockSigner) sign(req *http.Request, body []byte, now time.Time) error {
+	if s.accessKeyID == "" || s.secretAccessKey == "" {
+		return errors.New("no AWS credentials configured for bedrock provider")
+	}
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// Only sign the headers we control; forwarded client headers are left unsigned
+	signed := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": payloadHash,
+	}
+	if s.sessionToken != "" {
+		signed["x-amz-security-token"] = s.sessionToken
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Services other than S3 encode the already escaped path a second time
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		segments[i] = transform.AWSURIEncode(segment)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		strings.Join(segments, "/"),
+		canonicalQueryString(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/bedrock/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "bedrock")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalQueryString returns the sorted, encoded query string used in the signature
+func canonicalQueryString(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, transform.AWSURIEncode(key)+"="+transform.AWSURIEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
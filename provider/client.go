@@ -1,40 +1,72 @@
 package provider
 
 import (
+	"anthropic-proxy/config"
 	"anthropic-proxy/logger"
 	"anthropic-proxy/retry"
 	"anthropic-proxy/transform"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"time"
 )
 
+// strippedHeaders are client headers never forwarded to a provider: the client's
+// credentials for the proxy, and Accept-Encoding to prevent compressed responses
+// that we can't decompress. Keys are in canonical form.
+var strippedHeaders = map[string]bool{
+	"Authorization":   true,
+	"X-Api-Key":       true,
+	"X-Goog-Api-Key":  true,
+	"Accept-Encoding": true,
+}
+
 // Client handles HTTP communication with a provider
 type Client struct {
 	endpoint     string
-	apiKey       string
 	providerType string
+	adapter      Adapter
 	httpClient   *http.Client
+	timeouts     Timeouts
+	countTokens  bool
 }
 
-// NewClient creates a new provider client
-func NewClient(endpoint, apiKey, providerType string) *Client {
-	return &Client{
-		endpoint:     endpoint,
-		apiKey:       apiKey,
-		providerType: providerType,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second, // 2 minutes for long streaming requests
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
+// NewClient creates a new provider client using the adapter registered for the provider type
+func NewClient(providerConfig config.Provider) (*Client, error) {
+	timeouts := newTimeouts(providerConfig)
+	dialer := &net.Dialer{
+		Timeout:   timeouts.Connect,
+		KeepAlive: 30 * time.Second,
+	}
+	httpClient := &http.Client{
+		// Backstop for every caller; the proxy enforces the finer-grained timeouts itself
+		Timeout: timeouts.Total,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeouts.Connect,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
 		},
 	}
+
+	spec, err := adapterSpec(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		endpoint:     providerConfig.GetEndpoint(),
+		providerType: providerConfig.GetType(),
+		adapter:      spec.New(providerConfig, httpClient),
+		httpClient:   httpClient,
+		timeouts:     timeouts,
+		countTokens:  spec.CountTokens,
+	}, nil
 }
 
 // GetProviderType returns the provider type
@@ -42,22 +74,24 @@ func (c *Client) GetProviderType() string {
 	return c.providerType
 }
 
-// ProxyRequest forwards a request to the provider
-func (c *Client) ProxyRequest(ctx context.Context, method, path string, body []byte, headers map[string]string) (*http.Response, error) {
-	// Convert request format if needed for OpenAI providers
-	requestBody := body
-	requestPath := path
+// Timeouts returns the provider's configured timeouts
+func (c *Client) Timeouts() Timeouts {
+	return c.timeouts
+}
 
-	if c.providerType == transform.ProviderTypeOpenAI {
-		// Convert Anthropic request to OpenAI format
-		convertedBody, err := transform.AnthropicToOpenAIRequest(body)
-		if err != nil {
-			logger.Error("Failed to convert Anthropic request to OpenAI format", "error", err.Error())
-			return nil, fmt.Errorf("failed to convert request format: %w", err)
-		}
-		requestBody = convertedBody
-		// OpenAI uses /v1/chat/completions instead of /v1/messages
-		requestPath = "/v1/chat/completions"
+// CountsTokens reports whether the provider serves /v1/messages/count_tokens
+func (c *Client) CountsTokens() bool {
+	return c.countTokens
+}
+
+// ProxyRequest forwards an Anthropic request to the provider, encoded by the provider's adapter
+func (c *Client) ProxyRequest(ctx context.Context, method, path string, body []byte, headers map[string]string) (*http.Response, error) {
+	requestBody, requestPath, err := c.adapter.EncodeRequest(path, body, headers)
+	if err != nil {
+		logger.Error("Failed to convert Anthropic request for provider",
+			"providerType", c.providerType,
+			"error", err.Error())
+		return nil, fmt.Errorf("failed to convert request format: %w", err)
 	}
 
 	url := c.endpoint + requestPath
@@ -67,24 +101,24 @@ func (c *Client) ProxyRequest(ctx context.Context, method, path string, body []b
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set authorization header based on provider type
-	if c.providerType == transform.ProviderTypeOpenAI {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	} else {
-		// Anthropic
-		req.Header.Set("x-api-key", c.apiKey)
-		req.Header.Set("anthropic-version", "2023-06-01")
-	}
-
 	req.Header.Set("Content-Type", "application/json")
 
 	// Copy other headers from the original request
 	for key, value := range headers {
-		// Don't override Authorization, x-api-key, anthropic-version, or Accept-Encoding
-		// Skip Accept-Encoding to prevent compressed responses that we can't decompress
-		if key != "Authorization" && key != "x-api-key" && key != "anthropic-version" && key != "Accept-Encoding" {
-			req.Header.Set(key, value)
+		key = http.CanonicalHeaderKey(key)
+		if strippedHeaders[key] {
+			continue
+		}
+		// Only the Anthropic API knows the anthropic-version header
+		if key == "Anthropic-Version" && c.providerType != transform.ProviderTypeAnthropic {
+			continue
 		}
+		req.Header.Set(key, value)
+	}
+
+	// Authenticate last so signatures cover the final request
+	if err := c.adapter.Authenticate(ctx, req, requestBody); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -95,6 +129,50 @@ func (c *Client) ProxyRequest(ctx context.Context, method, path string, body []b
 	return resp, nil
 }
 
+// DecodeResponse converts a successful non-streaming response body to Anthropic format and extracts its usage
+func (c *Client) DecodeResponse(body []byte, model string) ([]byte, transform.AnthropicUsage, error) {
+	anthropicBody, err := c.adapter.DecodeResponse(body, model)
+	if err != nil {
+		return nil, transform.AnthropicUsage{}, err
+	}
+	return anthropicBody, responseUsage(anthropicBody), nil
+}
+
+// DecodeStream converts a successful streaming response body to an Anthropic SSE stream
+func (c *Client) DecodeStream(body io.ReadCloser, model string) io.ReadCloser {
+	return c.adapter.DecodeStream(body, model)
+}
+
+// RequestURL returns the provider URL an Anthropic request would be sent to
+func (c *Client) RequestURL(path string, body []byte) string {
+	if _, requestPath, err := c.adapter.EncodeRequest(path, body, nil); err == nil {
+		return c.endpoint + requestPath
+	}
+	return c.endpoint + path
+}
+
+// responseUsage extracts token usage from an Anthropic response body
+func responseUsage(anthropicBody []byte) transform.AnthropicUsage {
+	var response struct {
+		Usage struct {
+			transform.AnthropicUsage
+			// Some Anthropic-compatible providers report OpenAI-style usage
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(anthropicBody, &response)
+
+	usage := response.Usage.AnthropicUsage
+	if response.Usage.PromptTokens > 0 {
+		usage.InputTokens = response.Usage.PromptTokens
+	}
+	if response.Usage.CompletionTokens > 0 {
+		usage.OutputTokens = response.Usage.CompletionTokens
+	}
+	return usage
+}
+
 // StreamRequest makes a streaming request to the provider
 func (c *Client) StreamRequest(ctx context.Context, method, path string, body []byte, headers map[string]string) (*http.Response, error) {
 	// Same as ProxyRequest but ensures we don't close the body prematurely
@@ -111,12 +189,21 @@ func ReadBody(resp *http.Response) ([]byte, error) {
 	return body, nil
 }
 
-// ProxyRequestWithRetry forwards a request to the provider with retry logic
+// ProxyRequestWithRetry forwards a request to the provider with retry logic. Retries
+// wait for the delay the provider advertises in its rate limit headers, if any. When
+// every attempt is rejected the last rejected response is returned for the caller to inspect.
 func (c *Client) ProxyRequestWithRetry(ctx context.Context, method, path string, body []byte, headers map[string]string, retryConfig *retry.Config, providerName string) (*http.Response, error) {
 	var resp *http.Response
 	var lastErr error
 
 	err := retry.Do(ctx, retryConfig, func() error {
+		// Discard the previous rejected response before trying again
+		if resp != nil {
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp = nil
+		}
+
 		// Make the request
 		r, err := c.ProxyRequest(ctx, method, path, body, headers)
 		if err != nil {
@@ -127,25 +214,27 @@ func (c *Client) ProxyRequestWithRetry(ctx context.Context, method, path string,
 			return err
 		}
 
+		resp = r
+
 		// Check if status code is retriable
 		if retry.IsRetriable(r.StatusCode) {
-			// Read and discard the body before retrying
-			io.ReadAll(r.Body)
-			r.Body.Close()
-
+			delay := retry.RateLimitDelay(r.StatusCode, r.Header, time.Now())
 			logger.Debug("Received retriable status code, will retry",
 				"provider", providerName,
-				"statusCode", r.StatusCode)
+				"statusCode", r.StatusCode,
+				"retryAfter", delay.String())
 			lastErr = fmt.Errorf("retriable status code: %d", r.StatusCode)
-			return lastErr
+			return retry.After(lastErr, delay)
 		}
 
 		// Success or non-retriable error
-		resp = r
 		return nil
 	})
 
 	if err != nil {
+		if resp != nil {
+			return resp, nil
+		}
 		return nil, fmt.Errorf("request failed after retries: %w", lastErr)
 	}
 
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyRequestHeaders(t *testing.T) {
+	clientHeaders := map[string]string{
+		"X-Api-Key":         "proxy-token",
+		"Authorization":     "Bearer proxy-token",
+		"X-Goog-Api-Key":    "proxy-token",
+		"Accept-Encoding":   "gzip",
+		"Anthropic-Version": "2023-01-01",
+		"anthropic-beta":    "tools-2024",
+	}
+
+	tests := []struct {
+		providerType string
+		want         map[string]string // Header values the provider should see; "" means absent
+	}{
+		{
+			providerType: transform.ProviderTypeAnthropic,
+			want: map[string]string{
+				"X-Api-Key":         "provider-key",
+				"Authorization":     "",
+				"X-Goog-Api-Key":    "",
+				"Anthropic-Version": "2023-01-01",
+				"Anthropic-Beta":    "tools-2024",
+			},
+		},
+		{
+			providerType: transform.ProviderTypeOpenAI,
+			want: map[string]string{
+				"X-Api-Key":         "",
+				"Authorization":     "Bearer provider-key",
+				"X-Goog-Api-Key":    "",
+				"Anthropic-Version": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.providerType, func(t *testing.T) {
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+			}))
+			defer server.Close()
+
+			client, err := NewClient(config.Provider{Type: tt.providerType, Endpoint: server.URL, APIKey: "provider-key"})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			body := []byte(`{"model":"m","max_tokens":1,"messages":[{"role":"user","content":"hi"}]}`)
+			resp, err := client.ProxyRequest(context.Background(), http.MethodPost, "/v1/messages", body, clientHeaders)
+			if err != nil {
+				t.Fatalf("ProxyRequest: %v", err)
+			}
+			resp.Body.Close()
+
+			for key, want := range tt.want {
+				if got := received.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
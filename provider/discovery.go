@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/logger"
+	"anthropic-proxy/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultDiscoveryInterval is how often a provider's model list is refreshed
+	DefaultDiscoveryInterval = time.Hour
+
+	// discoveryTimeout bounds one refresh of a provider's model list
+	discoveryTimeout = 30 * time.Second
+)
+
+// ModelCatalog receives the models discovered on providers
+type ModelCatalog interface {
+	SetDiscoveredModels(providerName string, models []config.Model)
+}
+
+// ListedModel is a model a provider lists at GET /v1/models
+type ListedModel struct {
+	ID          string
+	DisplayName string    // Anthropic display name, if listed
+	CreatedAt   time.Time // Release date; zero when the provider does not report one
+	Context     int       // Context window; 0 when the provider does not report one
+}
+
+// ListModels fetches the models a provider serves from GET /v1/models, accepting the
+// Anthropic and OpenAI formats and following Anthropic pagination
+func (c *Client) ListModels(ctx context.Context) ([]ListedModel, error) {
+	var models []ListedModel
+	afterID := ""
+	for {
+		requestURL := c.endpoint + "/v1/models"
+		if afterID != "" {
+			requestURL += "?after_id=" + url.QueryEscape(afterID)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if err := c.adapter.Authenticate(ctx, req, nil); err != nil {
+			return nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, err := ReadBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing models returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var page struct {
+			Data []struct {
+				ID             string      `json:"id"`
+				DisplayName    string      `json:"display_name"`     // Anthropic
+				CreatedAt      interface{} `json:"created_at"`       // Anthropic, RFC 3339
+				Created        float64     `json:"created"`          // OpenAI, Unix seconds
+				MaxInputTokens int         `json:"max_input_tokens"` // Anthropic
+				ContextLength  int         `json:"context_length"`   // OpenRouter and other OpenAI-compatible APIs
+				ContextWindow  int         `json:"context_window"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse model list: %w", err)
+		}
+
+		for _, listed := range page.Data {
+			if listed.ID == "" {
+				continue
+			}
+			contextWindow := listed.MaxInputTokens
+			if listed.ContextLength > contextWindow {
+				contextWindow = listed.ContextLength
+			}
+			if listed.ContextWindow > contextWindow {
+				contextWindow = listed.ContextWindow
+			}
+			var createdAt time.Time
+			if value, ok := listed.CreatedAt.(string); ok {
+				createdAt, _ = time.Parse(time.RFC3339, value)
+			}
+			if createdAt.IsZero() && listed.Created > 0 {
+				createdAt = time.Unix(int64(listed.Created), 0).UTC()
+			}
+			models = append(models, ListedModel{
+				ID:          listed.ID,
+				DisplayName: listed.DisplayName,
+				CreatedAt:   createdAt,
+				Context:     contextWindow,
+			})
+		}
+
+		if !page.HasMore || page.LastID == "" || page.LastID == afterID {
+			return models, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+// discoveredModels turns a provider's model list into registry models. The first
+// discoverModels entry whose pattern matches a model ID supplies its settings; models
+// matching no entry are skipped unless there are no entries at all.
+func discoveredModels(providerName string, settings config.DiscoverModels, listed []ListedModel) []config.Model {
+	var models []config.Model
+	for _, item := range listed {
+		var registered config.Model
+		if len(settings.Models) > 0 {
+			matched := false
+			for _, entry := range settings.Models {
+				if model.MatchAlias(entry.Match, item.ID) {
+					registered = entry.Model
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		registered.Name = item.ID
+		registered.Provider = providerName
+		registered.Discovered = true
+		registered.CreatedAt = item.CreatedAt
+		if item.Context > 0 {
+			registered.Context = item.Context
+		}
+		if registered.DisplayName == "" {
+			registered.DisplayName = item.DisplayName
+		}
+		models = append(models, registered)
+	}
+	return models
+}
+
+// StartDiscovery registers the models of every provider with discoverModels in catalog,
+// now and then on each provider's interval. Providers added or changed later are picked up.
+func (m *Manager) StartDiscovery(catalog ModelCatalog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.catalog = catalog
+	for _, provider := range m.providers {
+		m.startDiscovery(provider)
+	}
+}
+
+// StopDiscovery stops refreshing provider model lists
+func (m *Manager) StopDiscovery() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, provider := range m.providers {
+		stopDiscovery(provider)
+	}
+	m.catalog = nil
+}
+
+// startDiscovery starts refreshing a provider's model list if it asks for it; callers hold the lock
+func (m *Manager) startDiscovery(provider *Provider) {
+	if m.catalog == nil || provider.settings.DiscoverModels == nil {
+		return
+	}
+	provider.stopDiscovery = make(chan struct{})
+	go m.discover(provider, provider.stopDiscovery)
+}
+
+// stopDiscovery stops refreshing a provider's model list; callers hold the lock
+func stopDiscovery(provider *Provider) {
+	if provider.stopDiscovery != nil {
+		close(provider.stopDiscovery)
+		provider.stopDiscovery = nil
+	}
+}
+
+// discover refreshes a provider's model list until stopped
+func (m *Manager) discover(provider *Provider, stop <-chan struct{}) {
+	interval := parseTimeout(provider.settings.DiscoverModels.Interval, DefaultDiscoveryInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.refreshModels(provider)
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// refreshModels lists a provider's models and registers them. On failure the models
+// discovered earlier are kept.
+func (m *Manager) refreshModels(provider *Provider) {
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	listed, err := provider.Client.ListModels(ctx)
+	if err != nil {
+		logger.Warn("Failed to discover provider models",
+			"provider", provider.Name,
+			"error", err.Error())
+		return
+	}
+	models := discoveredModels(provider.Name, *provider.settings.DiscoverModels, listed)
+
+	// Only register the models if the provider was not replaced or removed meanwhile
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.providers[provider.Name] != provider || m.catalog == nil {
+		return
+	}
+	m.catalog.SetDiscoveredModels(provider.Name, models)
+
+	logger.Debug("Discovered provider models",
+		"provider", provider.Name,
+		"listed", len(listed),
+		"registered", len(models))
+}
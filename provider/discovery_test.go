@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListModels(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages map[string]string // Response body by after_id
+		want  []ListedModel
+	}{
+		{
+			name: "anthropic with pagination",
+			pages: map[string]string{
+				"": `{"data":[{"id":"claude-opus-4","display_name":"Claude Opus 4","created_at":"2025-05-22T00:00:00Z","max_input_tokens":200000}],
+					"has_more":true,"last_id":"claude-opus-4"}`,
+				"claude-opus-4": `{"data":[{"id":"claude-haiku-4","created_at":"2025-10-01T00:00:00Z"}],"has_more":false,"last_id":"claude-haiku-4"}`,
+			},
+			want: []ListedModel{
+				{ID: "claude-opus-4", DisplayName: "Claude Opus 4", CreatedAt: time.Date(2025, 5, 22, 0, 0, 0, 0, time.UTC), Context: 200000},
+				{ID: "claude-haiku-4", CreatedAt: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "openai compatible",
+			pages: map[string]string{
+				"": `{"object":"list","data":[{"id":"gpt-4o","created":1715367049},{"id":""},{"id":"llama-3","context_length":131072}]}`,
+			},
+			want: []ListedModel{
+				{ID: "gpt-4o", CreatedAt: time.Unix(1715367049, 0).UTC()},
+				{ID: "llama-3", Context: 131072},
+			},
+		},
+		{
+			name: "repeated last_id stops paging",
+			pages: map[string]string{
+				"":  `{"data":[{"id":"a"}],"has_more":true,"last_id":"a"}`,
+				"a": `{"data":[{"id":"b"}],"has_more":true,"last_id":"a"}`,
+			},
+			want: []ListedModel{{ID: "a"}, {ID: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page, ok := tt.pages[r.URL.Query().Get("after_id")]
+				if r.URL.Path != "/v1/models" || !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(page))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(config.Provider{Type: transform.ProviderTypeAnthropic, Endpoint: server.URL, APIKey: "key"})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			got, err := client.ListModels(context.Background())
+			if err != nil {
+				t.Fatalf("ListModels: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("models = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("model %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoveredModels(t *testing.T) {
+	listed := []ListedModel{
+		{ID: "claude-sonnet-4", DisplayName: "Claude Sonnet 4", Context: 200000},
+		{ID: "claude-haiku-4"},
+		{ID: "gpt-4o"},
+	}
+
+	tests := []struct {
+		name     string
+		settings config.DiscoverModels
+		want     []config.Model
+	}{
+		{
+			name: "no entries registers everything",
+			want: []config.Model{
+				{Name: "claude-sonnet-4", DisplayName: "Claude Sonnet 4", Context: 200000},
+				{Name: "claude-haiku-4"},
+				{Name: "gpt-4o"},
+			},
+		},
+		{
+			name: "first matching entry applies",
+			settings: config.DiscoverModels{Models: []config.DiscoveredModel{
+				{Match: "claude-sonnet-*", Model: config.Model{Alias: "sonnet", Context: 100000, DisplayName: "Sonnet"}},
+				{Match: "claude-*", Model: config.Model{Alias: "claude", Context: 100000}},
+			}},
+			want: []config.Model{
+				{Name: "claude-sonnet-4", Alias: "sonnet", DisplayName: "Sonnet", Context: 200000},
+				{Name: "claude-haiku-4", Alias: "claude", Context: 100000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := discoveredModels("acme", tt.settings, listed)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d models, want %d", len(got), len(tt.want))
+			}
+			for i, model := range got {
+				want := tt.want[i]
+				if model.Name != want.Name || model.Alias != want.Alias || model.DisplayName != want.DisplayName ||
+					model.Context != want.Context || model.Provider != "acme" || !model.Discovered {
+					t.Errorf("model %d = %+v, want %+v on provider acme", i, model, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultQueueTimeout is how long a request waits for capacity when every choice is saturated
+const DefaultQueueTimeout = 10 * time.Second
+
+// limits enforces a provider's client-side limits and those of its models. Requests
+// per minute and tokens per minute are token buckets that refill continuously and
+// allow a full minute's worth of burst; concurrency is a count of requests in flight.
+type limits struct {
+	provider *limiter
+	models   map[string]*limiter // Keyed by model name, created on first use
+
+	mu      sync.Mutex
+	changed chan struct{} // Closed and replaced whenever capacity is released
+}
+
+// limiter holds the state of one set of limits
+type limiter struct {
+	settings config.Limits
+	inFlight int
+	requests *tokenBucket // nil when unlimited
+	tokens   *tokenBucket // nil when unlimited
+}
+
+// tokenBucket refills at capacity per minute
+type tokenBucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func newLimits(settings config.Limits) *limits {
+	return &limits{
+		provider: newLimiter(settings),
+		models:   make(map[string]*limiter),
+		changed:  make(chan struct{}),
+	}
+}
+
+func newLimiter(settings config.Limits) *limiter {
+	l := &limiter{settings: settings}
+	if settings.RequestsPerMinute > 0 {
+		l.requests = newTokenBucket(settings.RequestsPerMinute)
+	}
+	if settings.TokensPerMinute > 0 {
+		l.tokens = newTokenBucket(settings.TokensPerMinute)
+	}
+	return l
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{capacity: float64(perMinute), level: float64(perMinute), updated: time.Now()}
+}
+
+// refill adds the tokens earned since the last update
+func (b *tokenBucket) refill(now time.Time) {
+	b.level += now.Sub(b.updated).Minutes() * b.capacity
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.updated = now
+}
+
+// want clamps a request to the bucket capacity so oversized requests can still run
+func (b *tokenBucket) want(amount float64) float64 {
+	if amount > b.capacity {
+		return b.capacity
+	}
+	return amount
+}
+
+// wait returns how long until amount tokens are available
+func (b *tokenBucket) wait(amount float64) time.Duration {
+	missing := b.want(amount) - b.level
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.capacity * float64(time.Minute))
+}
+
+// wait returns how long until the limiter can admit a request of the given size;
+// -1 means it has to wait for a request in flight to finish
+func (l *limiter) wait(tokens float64, now time.Time) time.Duration {
+	if l.settings.MaxConcurrent > 0 && l.inFlight >= l.settings.MaxConcurrent {
+		return -1
+	}
+	var wait time.Duration
+	if l.requests != nil {
+		l.requests.refill(now)
+		wait = l.requests.wait(1)
+	}
+	if l.tokens != nil {
+		l.tokens.refill(now)
+		if w := l.tokens.wait(tokens); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// take admits a request; callers checked wait first
+func (l *limiter) take(tokens float64) {
+	l.inFlight++
+	if l.requests != nil {
+		l.requests.level--
+	}
+	if l.tokens != nil {
+		l.tokens.level -= l.tokens.want(tokens)
+	}
+}
+
+// limitersFor returns the provider limiter and the model limiter; models without limits
+// still get one to count requests in flight. Callers hold the lock.
+func (ls *limits) limitersFor(model *config.Model) []*limiter {
+	limiters := []*limiter{ls.provider}
+	if model == nil {
+		return limiters
+	}
+	l, exists := ls.models[model.Name]
+	if !exists {
+		l = newLimiter(model.Limits)
+		ls.models[model.Name] = l
+	} else if l.settings != model.Limits {
+		// The model's limits were reloaded; update in place so requests in flight keep counting
+		inFlight := l.inFlight
+		*l = *newLimiter(model.Limits)
+		l.inFlight = inFlight
+	}
+	return append(limiters, l)
+}
+
+// tryAcquire admits a request if every limit allows it right now; otherwise it returns
+// how long to wait (-1 for a request in flight to finish)
+func (ls *limits) tryAcquire(model *config.Model, tokens int) (func(), time.Duration) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := time.Now()
+	limiters := ls.limitersFor(model)
+	var wait time.Duration
+	for _, l := range limiters {
+		w := l.wait(float64(tokens), now)
+		if w < 0 || wait < 0 {
+			wait = -1
+		} else if w > wait {
+			wait = w
+		}
+	}
+	if wait != 0 {
+		return nil, wait
+	}
+
+	for _, l := range limiters {
+		l.take(float64(tokens))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { ls.release(limiters) })
+	}, 0
+}
+
+// release frees the concurrency slots of a finished request and wakes up waiters
+func (ls *limits) release(limiters []*limiter) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	for _, l := range limiters {
+		l.inFlight--
+	}
+	close(ls.changed)
+	ls.changed = make(chan struct{})
+}
+
+// recordTokens charges tokens used beyond what was reserved up front
+func (ls *limits) recordTokens(model *config.Model, tokens int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := time.Now()
+	for _, l := range ls.limitersFor(model) {
+		if l.tokens != nil {
+			l.tokens.refill(now)
+			l.tokens.level -= float64(tokens)
+		}
+	}
+}
+
+// TryAcquire reserves capacity for a request to one of the provider's models without
+// waiting. tokens is the estimated input size. The returned release must be called when
+// the request finishes; ok is false if a limit is reached.
+func (p *Provider) TryAcquire(model *config.Model, tokens int) (release func(), ok bool) {
+	release, wait := p.limits.tryAcquire(model, tokens)
+	return release, wait == 0
+}
+
+// Acquire reserves capacity like TryAcquire, waiting up to the provider's queue timeout
+func (p *Provider) Acquire(ctx context.Context, model *config.Model, tokens int) (func(), error) {
+	deadline := time.NewTimer(p.QueueTimeout)
+	defer deadline.Stop()
+
+	for {
+		p.limits.mu.Lock()
+		changed := p.limits.changed
+		p.limits.mu.Unlock()
+
+		release, wait := p.limits.tryAcquire(model, tokens)
+		if wait == 0 {
+			return release, nil
+		}
+
+		// Wait for a bucket to refill or, at the concurrency cap, for a request to finish
+		var refilled <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			refilled = timer.C
+		}
+
+		var err error
+		select {
+		case <-changed:
+		case <-refilled:
+		case <-deadline.C:
+			err = fmt.Errorf("provider %s: client-side limits still reached after waiting %s", p.Name, p.QueueTimeout)
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Saturated reports whether a request to the model would have to wait for capacity
+func (p *Provider) Saturated(model *config.Model) bool {
+	p.limits.mu.Lock()
+	defer p.limits.mu.Unlock()
+
+	now := time.Now()
+	for _, l := range p.limits.limitersFor(model) {
+		if l.wait(0, now) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// InFlight returns the number of requests in flight to one of the provider's models
+func (p *Provider) InFlight(model *config.Model) int {
+	p.limits.mu.Lock()
+	defer p.limits.mu.Unlock()
+
+	limiters := p.limits.limitersFor(model)
+	return limiters[len(limiters)-1].inFlight
+}
+
+// RecordTokens charges the tokens a finished request produced against the tokens per minute limits
+func (p *Provider) RecordTokens(model *config.Model, tokens int) {
+	if tokens > 0 {
+		p.limits.recordTokens(model, tokens)
+	}
+}
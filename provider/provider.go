@@ -3,22 +3,34 @@ package provider
 import (
 	"anthropic-proxy/config"
 	"anthropic-proxy/logger"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 )
 
 // Manager manages provider configurations and health
 type Manager struct {
 	providers map[string]*Provider
+	catalog   ModelCatalog // Receives discovered models; nil until discovery is started
 	mu        sync.RWMutex
 }
 
 // Provider represents a backend provider with its configuration
 type Provider struct {
-	Name     string
-	Type     string // "anthropic" or "openai"
-	Endpoint string
-	APIKey   string
-	Client   *Client
+	Name            string
+	Type            string // "anthropic", "openai", "responses", "gemini", "bedrock" or "vertex"
+	Endpoint        string
+	APIKey          string
+	ReasoningFormat string        // How thinking budgets are sent to OpenAI providers
+	QueueTimeout    time.Duration // How long a request waits for capacity when every choice is saturated
+	Client          *Client
+
+	limits *limits // Client-side request limits
+
+	settings config.Provider // Configuration the provider was built from
+
+	stopDiscovery chan struct{} // Closed to stop refreshing the model list; nil without discovery
 }
 
 // NewManager creates a new provider manager
@@ -34,17 +46,35 @@ func (m *Manager) Load(providers map[string]config.Provider) {
 	defer m.mu.Unlock()
 
 	for name, providerConfig := range providers {
-		provider := &Provider{
-			Name:     name,
-			Type:     providerConfig.GetType(),
-			Endpoint: providerConfig.Endpoint,
-			APIKey:   providerConfig.APIKey,
-			Client:   NewClient(providerConfig.Endpoint, providerConfig.APIKey, providerConfig.GetType()),
+		provider, err := newProvider(name, providerConfig)
+		if err != nil {
+			logger.Error("Failed to load provider", "provider", name, "error", err.Error())
+			continue
 		}
 		m.providers[name] = provider
 	}
 }
 
+// newProvider builds a provider and its client from configuration
+func newProvider(name string, providerConfig config.Provider) (*Provider, error) {
+	client, err := NewClient(providerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", name, err)
+	}
+
+	return &Provider{
+		Name:            name,
+		Type:            providerConfig.GetType(),
+		Endpoint:        providerConfig.GetEndpoint(),
+		APIKey:          providerConfig.APIKey,
+		ReasoningFormat: providerConfig.ReasoningFormat,
+		QueueTimeout:    parseTimeout(providerConfig.QueueTimeout, DefaultQueueTimeout),
+		Client:          client,
+		limits:          newLimits(providerConfig.Limits),
+		settings:        providerConfig,
+	}, nil
+}
+
 // Get returns a provider by name
 func (m *Manager) Get(name string) (*Provider, bool) {
 	m.mu.RLock()
@@ -84,45 +114,50 @@ func (m *Manager) UpdateProviders(newProviders map[string]config.Provider) {
 	// Update existing providers and add new ones
 	for name, providerConfig := range newProviders {
 		activeProviders[name] = true
-		providerType := providerConfig.GetType()
 
 		if existingProvider, exists := m.providers[name]; exists {
 			// Check if provider configuration actually changed
-			if existingProvider.Type != providerType || existingProvider.Endpoint != providerConfig.Endpoint || existingProvider.APIKey != providerConfig.APIKey {
+			if !reflect.DeepEqual(existingProvider.settings, providerConfig) {
 				logger.Info("Updating provider configuration",
 					"provider", name,
 					"oldEndpoint", existingProvider.Endpoint,
-					"newEndpoint", providerConfig.Endpoint,
-					"type", providerType)
+					"newEndpoint", providerConfig.GetEndpoint(),
+					"type", providerConfig.GetType())
 
 				// Create new provider with updated config
-				updatedProvider := &Provider{
-					Name:     name,
-					Type:     providerType,
-					Endpoint: providerConfig.Endpoint,
-					APIKey:   providerConfig.APIKey,
-					Client:   NewClient(providerConfig.Endpoint, providerConfig.APIKey, providerType),
+				provider, err := newProvider(name, providerConfig)
+				if err != nil {
+					logger.Error("Failed to update provider", "provider", name, "error", err.Error())
+					continue
 				}
-				m.providers[name] = updatedProvider
+				stopDiscovery(existingProvider)
+				if providerConfig.DiscoverModels == nil && m.catalog != nil {
+					m.catalog.SetDiscoveredModels(name, nil)
+				}
+				m.providers[name] = provider
+				m.startDiscovery(provider)
 				logger.Info("Provider updated successfully", "provider", name)
 			}
 		} else {
 			// Add new provider
-			provider := &Provider{
-				Name:     name,
-				Type:     providerType,
-				Endpoint: providerConfig.Endpoint,
-				APIKey:   providerConfig.APIKey,
-				Client:   NewClient(providerConfig.Endpoint, providerConfig.APIKey, providerType),
+			provider, err := newProvider(name, providerConfig)
+			if err != nil {
+				logger.Error("Failed to add provider", "provider", name, "error", err.Error())
+				continue
 			}
 			m.providers[name] = provider
+			m.startDiscovery(provider)
 			logger.Info("Provider added successfully", "provider", name)
 		}
 	}
 
 	// Remove providers that are no longer in the config
-	for name := range m.providers {
+	for name, provider := range m.providers {
 		if !activeProviders[name] {
+			stopDiscovery(provider)
+			if m.catalog != nil {
+				m.catalog.SetDiscoveredModels(name, nil)
+			}
 			delete(m.providers, name)
 			logger.Info("Provider removed", "provider", name)
 		}
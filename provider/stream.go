@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"anthropic-proxy/logger"
+	"anthropic-proxy/transform"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// convertedStream turns a provider SSE stream into Anthropic SSE with a stream
+// converter. Upstream event names are replaced by the converted events, while
+// comments are passed through so keep-alives still reach the client.
+type convertedStream struct {
+	body      io.ReadCloser
+	reader    *bufio.Reader
+	converter transform.StreamConverter
+	pending   bytes.Buffer
+	err       error // Read error returned once pending output is drained
+}
+
+func newConvertedStream(body io.ReadCloser, converter transform.StreamConverter) *convertedStream {
+	return &convertedStream{
+		body:      body,
+		reader:    bufio.NewReader(body),
+		converter: converter,
+	}
+}
+
+// Read returns converted Anthropic SSE, reading upstream lines as needed
+func (s *convertedStream) Read(p []byte) (int, error) {
+	for s.pending.Len() == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+
+		line, err := s.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			s.convertLine(line)
+		}
+		if err == io.EOF {
+			s.writeEvents(s.converter.Finish())
+			if !s.converter.Finished() {
+				// The upstream stopped before it finished the message; report it so the
+				// proxy fails over instead of closing the message with a made-up stop reason
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err != nil {
+			s.err = err
+		}
+	}
+	return s.pending.Read(p)
+}
+
+// Close closes the upstream body
+func (s *convertedStream) Close() error {
+	return s.body.Close()
+}
+
+// convertLine converts a single upstream SSE line
+func (s *convertedStream) convertLine(line []byte) {
+	trimmed := strings.TrimSpace(string(line))
+
+	switch {
+	case strings.HasPrefix(trimmed, "data:"):
+		data := strings.TrimSpace(strings.TrimPrefix(trimmed, "data:"))
+		if data == "[DONE]" {
+			// Close the message if the upstream sent its finish reason but no usage
+			s.writeEvents(s.converter.Finish())
+			return
+		}
+
+		events, err := s.converter.Convert([]byte(data))
+		if err != nil {
+			logger.Error("Failed to convert provider stream to Anthropic format", "error", err.Error())
+			return
+		}
+		s.writeEvents(events)
+
+	case strings.HasPrefix(trimmed, ":"):
+		s.pending.WriteString(trimmed + "\n\n")
+	}
+}
+
+// writeEvents writes converted events in SSE format: "event: message_start\ndata: {...}\n\n"
+func (s *convertedStream) writeEvents(events []string) {
+	for _, event := range events {
+		var eventData struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal([]byte(event), &eventData)
+		if eventData.Type != "" {
+			s.pending.WriteString("event: " + eventData.Type + "\n")
+		}
+		s.pending.WriteString("data: " + event + "\n\n")
+	}
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"anthropic-proxy/transform"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConvertedStreamEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream string
+		wantErr  error
+		wantStop bool
+	}{
+		{
+			name: "finish reason and done",
+			upstream: "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantStop: true,
+		},
+		{
+			name:     "finish reason without done",
+			upstream: "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n",
+			wantStop: true,
+		},
+		{
+			name: "done without finish reason",
+			upstream: "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:     "cut off",
+			upstream: "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n",
+			wantErr:  io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := newConvertedStream(io.NopCloser(strings.NewReader(tt.upstream)), transform.NewOpenAIStreamConverter("model"))
+			output, err := io.ReadAll(stream)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if gotStop := strings.Contains(string(output), "event: message_stop"); gotStop != tt.wantStop {
+				t.Errorf("message_stop sent = %v, want %v\n%s", gotStop, tt.wantStop, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"time"
+)
+
+// Defaults for timeouts a provider does not configure
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultTotalTimeout   = 10 * time.Minute
+)
+
+// Timeouts bounds the phases of a provider request; a zero duration disables that timeout
+type Timeouts struct {
+	Connect    time.Duration // Establishing the TCP and TLS connection
+	FirstToken time.Duration // From sending the request to the first streamed content
+	IdleStream time.Duration // Longest silence between stream events
+	Total      time.Duration // The whole request, including reading the response
+}
+
+// newTimeouts parses the provider's timeout settings, applying the defaults.
+// The values were checked during config validation, so parse errors leave the default.
+func newTimeouts(settings config.Provider) Timeouts {
+	return Timeouts{
+		Connect:    parseTimeout(settings.ConnectTimeout, DefaultConnectTimeout),
+		FirstToken: parseTimeout(settings.FirstTokenTimeout, 0),
+		IdleStream: parseTimeout(settings.IdleStreamTimeout, 0),
+		Total:      parseTimeout(settings.TotalTimeout, DefaultTotalTimeout),
+	}
+}
+
+func parseTimeout(value string, defaultValue time.Duration) time.Duration {
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return d
+}
@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/transform"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vertexScope         = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenEndpoint = "https://oauth2.googleapis.com/token"
+)
+
+func init() {
+	RegisterAdapter(transform.ProviderTypeVertex, AdapterSpec{
+		New: func(settings config.Provider, httpClient *http.Client) Adapter {
+			return &vertexAdapter{
+				projectID:   settings.ProjectID,
+				region:      settings.Region,
+				credentials: newVertexCredentials(settings, httpClient),
+			}
+		},
+		Validate: func(settings config.Provider) error {
+			if settings.Region == "" {
+				return errors.New("region is required for vertex providers")
+			}
+			if settings.ProjectID == "" {
+				return errors.New("projectId is required for vertex providers")
+			}
+			return nil
+		},
+		DefaultEndpoint: func(settings config.Provider) string {
+			if settings.Region == "global" {
+				return "https://aiplatform.googleapis.com"
+			}
+			return "https://" + settings.Region + "-aiplatform.googleapis.com"
+		},
+		CountTokens: true,
+	})
+}
+
+// vertexAdapter sends Anthropic requests to the Vertex AI rawPredict API, which answers in Anthropic format
+type vertexAdapter struct {
+	anthropicResponses
+	projectID   string
+	region      string
+	credentials *vertexCredentials
+}
+
+func (a *vertexAdapter) EncodeRequest(path string, body []byte, headers map[string]string) ([]byte, string, error) {
+	return transform.AnthropicToVertexRequest(body, a.projectID, a.region, path == "/v1/messages/count_tokens")
+}
+
+func (a *vertexAdapter) Authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	token, err := a.credentials.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get vertex access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// vertexCredentials issues OAuth access tokens for Vertex AI from a Google credentials file.
+// Tokens are cached until shortly before they expire.
+type vertexCredentials struct {
+	credentialsFile string
+	httpClient      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// googleCredentials is a service account or authorized user (gcloud) credentials file
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// newVertexCredentials builds a token source from the provider config, falling back to GOOGLE_APPLICATION_CREDENTIALS
+func newVertexCredentials(providerConfig config.Provider, httpClient *http.Client) *vertexCredentials {
+	credentialsFile := providerConfig.CredentialsFile
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	return &vertexCredentials{
+		credentialsFile: credentialsFile,
+		httpClient:      httpClient,
+	}
+}
+
+// token returns a valid access token, refreshing it if needed
+func (v *vertexCredentials) token(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.accessToken != "" && time.Now().Before(v.expiry.Add(-time.Minute)) {
+		return v.accessToken, nil
+	}
+
+	if v.credentialsFile == "" {
+		return "", errors.New("no credentials file configured for vertex provider")
+	}
+	data, err := os.ReadFile(v.credentialsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vertex credentials: %w", err)
+	}
+	var creds googleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", fmt.Errorf("failed to parse vertex credentials: %w", err)
+	}
+
+	tokenURI := creds.TokenURI
+	if tokenURI == "" {
+		tokenURI = googleTokenEndpoint
+	}
+
+	form := url.Values{}
+	switch creds.Type {
+	case "service_account":
+		assertion, err := signServiceAccountJWT(creds, tokenURI, time.Now())
+		if err != nil {
+			return "", err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		form.Set("refresh_token", creds.RefreshToken)
+	default:
+		return "", fmt.Errorf("unsupported vertex credentials type %q", creds.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	v.accessToken = tokenResp.AccessToken
+	v.expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return v.accessToken, nil
+}
+
+// signServiceAccountJWT builds the RS256 signed assertion exchanged for an access token
+func signServiceAccountJWT(creds googleCredentials, audience string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", errors.New("vertex credentials contain no PEM private key")
+	}
+
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("vertex credentials private key is not an RSA key")
+		}
+		key = rsaKey
+	} else if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = rsaKey
+	} else {
+		return "", fmt.Errorf("failed to parse vertex credentials private key: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]interface{}{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": creds.PrivateKeyID,
+	})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   creds.ClientEmail,
+		"scope": vertexScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign vertex token assertion: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"anthropic-proxy/transform"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleChatCompletions handles POST /v1/chat/completions requests from OpenAI-compatible clients.
+// The request is converted to Anthropic format, routed like /v1/messages, and
+// the response is converted back to an OpenAI chat completion.
+func (h *Handler) HandleChatCompletions(c *gin.Context) {
+	// Read request body
+	bodyBytes, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, "invalid_request", "failed to read request body"))
+		return
+	}
+
+	var openaiReq transform.OpenAIRequest
+	if err := json.Unmarshal(bodyBytes, &openaiReq); err != nil {
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, "invalid_request", "invalid JSON in request body"))
+		return
+	}
+
+	// Convert to an Anthropic request
+	anthropicBody, err := transform.OpenAIToAnthropicRequest(bodyBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, string(ErrorTypeInvalidRequest), err.Error()))
+		return
+	}
+
+	var requestBody map[string]interface{}
+	if err := json.Unmarshal(anthropicBody, &requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, "invalid_request", "invalid JSON in request body"))
+		return
+	}
+
+	format := responseFormat{
+		openAI:       true,
+		includeUsage: openaiReq.StreamOptions != nil && openaiReq.StreamOptions.IncludeUsage,
+	}
+	h.proxyMessages(c, requestBody, format)
+}
@@ -2,27 +2,36 @@ package proxy
 
 import (
 	"anthropic-proxy/logger"
+	"anthropic-proxy/provider"
 	"anthropic-proxy/router"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// tokenCountHeader marks count_tokens responses estimated by the proxy rather than counted by a provider
+const tokenCountHeader = "X-Proxy-Token-Count"
+
 // CountTokensHandler handles token counting requests
 type CountTokensHandler struct {
 	fallbackMgr *router.FallbackManager
+	preferLocal bool // Always estimate locally instead of asking providers
 }
 
 // NewCountTokensHandler creates a new count tokens handler
-func NewCountTokensHandler(fallbackMgr *router.FallbackManager) *CountTokensHandler {
+func NewCountTokensHandler(fallbackMgr *router.FallbackManager, preferLocal bool) *CountTokensHandler {
 	return &CountTokensHandler{
 		fallbackMgr: fallbackMgr,
+		preferLocal: preferLocal,
 	}
 }
 
-// HandleCountTokens handles POST /v1/messages/count_tokens requests
+// HandleCountTokens handles POST /v1/messages/count_tokens requests. Providers that serve
+// the endpoint are asked in routing order; when none can or all fail, or local counting
+// is preferred, the prompt is estimated locally.
 func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 	// Read request body
 	bodyBytes, err := io.ReadAll(c.Request.Body)
@@ -45,21 +54,27 @@ func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 		return
 	}
 
-	// Check if thinking is enabled in the request
-	thinkingEnabled := false
-	if thinking, ok := requestBody["thinking"].(map[string]interface{}); ok {
-		if thinkingType, ok := thinking["type"].(string); ok && thinkingType == "enabled" {
-			thinkingEnabled = true
-		}
+	if h.preferLocal {
+		h.countLocally(c, modelName, requestBody)
+		return
 	}
 
+	// Count with the models the request would be routed to, rules included
+	route := routeRequest(c, modelName, requestBody)
+	h.fallbackMgr.ApplyRules(&route)
+
 	// Get ordered list of providers to try
-	providerChoices, err := h.fallbackMgr.GetOrderedProviders(modelName, thinkingEnabled)
+	providerChoices, err := h.fallbackMgr.GetOrderedProviders(route)
 	if err != nil {
-		logger.Error("Error selecting providers for model",
-			"model", modelName,
+		// Unknown models are an error; otherwise no provider can count, so estimate instead
+		if errors.Is(err, router.ErrNoModelFound) {
+			c.JSON(http.StatusNotFound, CreateErrorResponse(404, "not_found_error", "model not found: "+route.Model))
+			return
+		}
+		logger.Warn("No providers available for token counting, estimating locally",
+			"model", route.Model,
 			"error", err.Error())
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "no_providers", err.Error()))
+		h.countLocally(c, modelName, requestBody)
 		return
 	}
 
@@ -74,6 +89,11 @@ func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 	// Try each provider in order
 	var lastError *ProxyError
 	for _, choice := range providerChoices {
+		// Providers without a token counting endpoint would run a completion instead
+		if !choice.Provider.Client.CountsTokens() {
+			continue
+		}
+
 		// Update request body with the actual model name for this provider
 		requestBody["model"] = choice.ActualModel
 		updatedBody, err := json.Marshal(requestBody)
@@ -96,18 +116,9 @@ func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 			lastError = proxyErr
 			continue
 		}
-		defer resp.Body.Close()
 
-		// Check status code
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			proxyErr := ClassifyError(resp.StatusCode, nil, choice.Provider.Name)
-			LogError(proxyErr)
-			lastError = proxyErr
-			continue
-		}
-
-		// Success! Read and return response
-		responseBody, err := io.ReadAll(resp.Body)
+		// Read the whole response so the connection is released before trying the next provider
+		responseBody, err := provider.ReadBody(resp)
 		if err != nil {
 			logger.Error("Error reading response from provider",
 				"provider", choice.Provider.Name,
@@ -116,6 +127,14 @@ func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 			continue
 		}
 
+		// Check status code
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			proxyErr := ClassifyError(resp.StatusCode, nil, choice.Provider.Name)
+			LogError(proxyErr)
+			lastError = proxyErr
+			continue
+		}
+
 		// Parse the response to log token count
 		var tokenResponse map[string]interface{}
 		if err := json.Unmarshal(responseBody, &tokenResponse); err == nil {
@@ -138,15 +157,22 @@ func (h *CountTokensHandler) HandleCountTokens(c *gin.Context) {
 		return
 	}
 
-	// All providers failed
-	logger.Error("All providers failed for token counting",
-		"model", modelName)
-
 	if lastError != nil {
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
-			"All providers failed: "+lastError.Message))
-	} else {
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
-			"All providers failed to process the token counting request"))
+		logger.Warn("All providers failed for token counting, estimating locally",
+			"model", modelName,
+			"error", lastError.Message)
 	}
+	h.countLocally(c, modelName, requestBody)
+}
+
+// countLocally answers with the proxy's own estimate of the prompt size
+func (h *CountTokensHandler) countLocally(c *gin.Context, modelName string, requestBody map[string]interface{}) {
+	inputTokens := estimatePromptTokens(requestBody)
+
+	logger.Debug("Estimated token count locally",
+		"model", modelName,
+		"inputTokens", inputTokens)
+
+	c.Header(tokenCountHeader, "estimated")
+	c.JSON(http.StatusOK, gin.H{"input_tokens": inputTokens})
 }
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"anthropic-proxy/logger"
+	"anthropic-proxy/model"
+	"anthropic-proxy/router"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitQuiet("error")
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func TestHandleCountTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferLocal bool
+		body        string
+		wantStatus  int
+		wantType    string // Error type, if the request fails
+		wantHeader  string // X-Proxy-Token-Count
+	}{
+		{
+			name:       "missing model",
+			body:       `{"messages":[]}`,
+			wantStatus: http.StatusBadRequest,
+			wantType:   "invalid_request",
+		},
+		{
+			name:       "unknown model",
+			body:       `{"model":"no-such-model","messages":[{"role":"user","content":"hi"}]}`,
+			wantStatus: http.StatusNotFound,
+			wantType:   "not_found_error",
+		},
+		{
+			name:        "local counting",
+			preferLocal: true,
+			body:        `{"model":"no-such-model","messages":[{"role":"user","content":"hi"}]}`,
+			wantStatus:  http.StatusOK,
+			wantHeader:  "estimated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := router.NewSelector(model.NewRegistry(), nil, nil, nil, 40, false)
+			handler := NewCountTokensHandler(router.NewFallbackManager(selector), tt.preferLocal)
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", strings.NewReader(tt.body))
+			handler.HandleCountTokens(c)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if got := recorder.Header().Get(tokenCountHeader); got != tt.wantHeader {
+				t.Errorf("%s = %q, want %q", tokenCountHeader, got, tt.wantHeader)
+			}
+
+			var response struct {
+				InputTokens int `json:"input_tokens"`
+				Error       struct {
+					Type string `json:"type"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response %s: %v", recorder.Body, err)
+			}
+			if response.Error.Type != tt.wantType {
+				t.Errorf("error type = %q, want %q", response.Error.Type, tt.wantType)
+			}
+			if tt.wantType == "" && response.InputTokens <= 0 {
+				t.Errorf("input_tokens = %d, want a positive estimate", response.InputTokens)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package proxy
 
 import (
 	"anthropic-proxy/logger"
+	"anthropic-proxy/transform"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -10,13 +12,16 @@ import (
 type ErrorType string
 
 const (
-	ErrorTypeNetwork     ErrorType = "network_error"
-	ErrorTypeAuth        ErrorType = "authentication_error"
-	ErrorTypeRateLimit   ErrorType = "rate_limit_error"
-	ErrorTypeClient      ErrorType = "client_error"
-	ErrorTypeServer      ErrorType = "server_error"
-	ErrorTypeUnknown     ErrorType = "unknown_error"
-	ErrorTypeNoProviders ErrorType = "no_providers_error"
+	ErrorTypeNetwork        ErrorType = "network_error"
+	ErrorTypeAuth           ErrorType = "authentication_error"
+	ErrorTypeRateLimit      ErrorType = "rate_limit_error"
+	ErrorTypeClient         ErrorType = "client_error"
+	ErrorTypeServer         ErrorType = "server_error"
+	ErrorTypeUnknown        ErrorType = "unknown_error"
+	ErrorTypeNoProviders    ErrorType = "no_providers_error"
+	ErrorTypeInvalidRequest ErrorType = "invalid_request_error"
+	ErrorTypeStream         ErrorType = "stream_error"
+	ErrorTypeTimeout        ErrorType = "timeout_error"
 )
 
 // ProxyError represents an error during proxying
@@ -44,6 +49,21 @@ func ClassifyError(statusCode int, err error, providerName string) *ProxyError {
 		Err:        err,
 	}
 
+	// Request content the provider format cannot express
+	if errors.Is(err, transform.ErrUnsupportedContent) {
+		proxyErr.Type = ErrorTypeInvalidRequest
+		proxyErr.StatusCode = http.StatusBadRequest
+		proxyErr.Message = err.Error()
+		return proxyErr
+	}
+
+	// A provider timeout configured for this provider
+	if errors.Is(err, errProviderTimeout) {
+		proxyErr.Type = ErrorTypeTimeout
+		proxyErr.Message = err.Error()
+		return proxyErr
+	}
+
 	// Network errors
 	if err != nil {
 		proxyErr.Type = ErrorTypeNetwork
@@ -81,6 +101,29 @@ func ClassifyError(statusCode int, err error, providerName string) *ProxyError {
 	return proxyErr
 }
 
+// StreamError creates an error for a provider stream that failed after the provider answered
+func StreamError(err error, providerName string) *ProxyError {
+	if errors.Is(err, errProviderTimeout) {
+		return ClassifyError(0, err, providerName)
+	}
+	return &ProxyError{
+		Type:     ErrorTypeStream,
+		Message:  fmt.Sprintf("stream error: %v", err),
+		Provider: providerName,
+		Err:      err,
+	}
+}
+
+// LimitError creates an error for a provider that stayed at its client-side limits
+func LimitError(err error, providerName string) *ProxyError {
+	return &ProxyError{
+		Type:     ErrorTypeRateLimit,
+		Message:  err.Error(),
+		Provider: providerName,
+		Err:      err,
+	}
+}
+
 // LogError logs a proxy error with context
 func LogError(err *ProxyError) {
 	if err == nil {
@@ -101,7 +144,7 @@ func ShouldRetry(err *ProxyError) bool {
 
 	// Retry on network errors, server errors, and rate limits
 	switch err.Type {
-	case ErrorTypeNetwork, ErrorTypeServer, ErrorTypeRateLimit:
+	case ErrorTypeNetwork, ErrorTypeServer, ErrorTypeRateLimit, ErrorTypeStream, ErrorTypeTimeout:
 		return true
 	case ErrorTypeAuth, ErrorTypeClient:
 		// Also retry auth and client errors with different providers
@@ -3,6 +3,7 @@ package proxy
 import (
 	"anthropic-proxy/analytics"
 	"anthropic-proxy/auth"
+	"anthropic-proxy/config"
 	"anthropic-proxy/logger"
 	"anthropic-proxy/metrics"
 	"anthropic-proxy/provider"
@@ -11,8 +12,11 @@ import (
 	"anthropic-proxy/router"
 	"anthropic-proxy/transform"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,10 +31,11 @@ type Handler struct {
 	retrySameProvider bool
 	requestLogger *requestlog.RequestLogger
 	analyticsService *analytics.Service
+	streamCommit  StreamCommitPolicy
 }
 
 // NewHandler creates a new proxy handler
-func NewHandler(fallbackMgr *router.FallbackManager, tracker *metrics.Tracker, errorTracker *metrics.ErrorTracker, retryConfig *retry.Config, retrySameProvider bool, requestLogger *requestlog.RequestLogger, analyticsService *analytics.Service) *Handler {
+func NewHandler(fallbackMgr *router.FallbackManager, tracker *metrics.Tracker, errorTracker *metrics.ErrorTracker, retryConfig *retry.Config, retrySameProvider bool, requestLogger *requestlog.RequestLogger, analyticsService *analytics.Service, streamCommit StreamCommitPolicy) *Handler {
 	return &Handler{
 		fallbackMgr:   fallbackMgr,
 		tracker:       tracker,
@@ -39,6 +44,7 @@ func NewHandler(fallbackMgr *router.FallbackManager, tracker *metrics.Tracker, e
 		retrySameProvider: retrySameProvider,
 		requestLogger: requestLogger,
 		analyticsService: analyticsService,
+		streamCommit:  streamCommit,
 	}
 }
 
@@ -58,6 +64,17 @@ func (h *Handler) HandleMessages(c *gin.Context) {
 		return
 	}
 
+	h.proxyMessages(c, requestBody, responseFormat{})
+}
+
+// responseFormat describes the API format responses are written back in
+type responseFormat struct {
+	openAI       bool // Client called /v1/chat/completions and expects OpenAI responses
+	includeUsage bool // OpenAI client asked for a final usage chunk when streaming
+}
+
+// proxyMessages routes an Anthropic messages request through the provider fallback chain
+func (h *Handler) proxyMessages(c *gin.Context, requestBody map[string]interface{}, format responseFormat) {
 	// Extract model name
 	modelName, ok := requestBody["model"].(string)
 	if !ok || modelName == "" {
@@ -71,66 +88,195 @@ func (h *Handler) HandleMessages(c *gin.Context) {
 		isStreaming = stream
 	}
 
-	// Check if thinking is enabled in the request
-	thinkingEnabled := false
-	if thinking, ok := requestBody["thinking"].(map[string]interface{}); ok {
-		if thinkingType, ok := thinking["type"].(string); ok && thinkingType == "enabled" {
-			thinkingEnabled = true
-		}
+	// Describe what the request needs so unsuitable models are skipped, then apply routing rules
+	route := routeRequest(c, modelName, requestBody)
+	h.fallbackMgr.ApplyRules(&route)
+	if route.Rule != "" {
+		c.Set(routingRuleKey, route.Rule)
 	}
 
-	// Get ordered list of providers to try
-	providerChoices, err := h.fallbackMgr.GetOrderedProviders(modelName, thinkingEnabled)
-	if err != nil {
-		logger.Error("Error selecting providers for model",
-			"model", modelName,
-			"error", err.Error())
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "no_providers", err.Error()))
+	// Routing override headers apply on top of the rules
+	if err := applyRoutingOverrides(c, &route); err != nil {
+		if errors.Is(err, errOverridesNotAllowed) || errors.Is(err, errOverrideNotAllowedByRule) {
+			c.JSON(http.StatusForbidden, CreateErrorResponse(403, "permission_error", err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, string(ErrorTypeInvalidRequest), err.Error()))
 		return
 	}
 
-	// Copy headers to forward
+	// Copy headers to forward; routing override headers are meant for the proxy only
 	headers := make(map[string]string)
 	for key, values := range c.Request.Header {
-		if len(values) > 0 && key != "Authorization" {
+		if len(values) > 0 && key != "Authorization" && !strings.HasPrefix(key, overrideHeaderPrefix) {
 			headers[key] = values[0]
 		}
 	}
 
-	// Track attempts per unique provider/model combo to avoid duplicate retries
-	attemptedCombos := make(map[string]struct{})
+	// Walk the alias and, when it cannot serve the request, its fallback aliases
+	state := &attemptState{inputTokens: route.InputTokens, tried: make(map[string]struct{})}
+	visited := map[string]bool{route.Model: true}
+	for {
+		var conditions []string
 
-	// Try each provider in order
-	var lastError *ProxyError
-	attemptNumber := 0
+		// Get ordered list of providers to try
+		providerChoices, err := h.fallbackMgr.GetOrderedProviders(route)
+		if err != nil {
+			logger.Error("Error selecting providers for model",
+				"model", route.Model,
+				"error", err.Error())
+			// An alias without a usable provider counts as failed so the next fallback is tried
+			conditions = []string{config.FallbackAllFailed}
+			var contextErr *router.ContextWindowError
+			if errors.As(err, &contextErr) {
+				conditions = []string{config.FallbackContextTooLarge}
+			}
+			if len(visited) == 1 {
+				state.selectErr = err
+			}
+		} else {
+			if route.NoFallback && len(providerChoices) > 1 {
+				providerChoices = providerChoices[:1]
+			}
+			if h.tryChoices(c, requestBody, providerChoices, headers, isStreaming, modelName, format, state) {
+				return // Success, response already sent
+			}
+
+			// The request itself could not be expressed for any provider
+			if state.invalidAttempts > 0 && state.invalidAttempts == state.attemptNumber {
+				break
+			}
+			conditions = []string{config.FallbackAllFailed}
+			if state.failures > 0 && state.limitedFailures == state.failures {
+				conditions = append(conditions, config.FallbackOverBudget)
+			}
+		}
+
+		if route.NoFallback {
+			break
+		}
+		next, ok := h.fallbackMgr.GetFallback(route.Model, conditions, visited)
+		if !ok {
+			break
+		}
+		logger.Warn("Falling back to another model alias",
+			"from", route.Model,
+			"to", next,
+			"conditions", conditions)
+
+		// Flag the downgrade; the response reports the model that actually served it
+		c.Set(downgradedFromKey, modelName)
+		route.Model = next
+	}
+
+	// Selecting providers for the requested model failed and no fallback was attempted
+	if state.selectErr != nil && state.attemptNumber == 0 {
+		var contextErr *router.ContextWindowError
+		var capabilityErr *router.CapabilityError
+		if errors.As(state.selectErr, &contextErr) || errors.As(state.selectErr, &capabilityErr) {
+			c.JSON(http.StatusBadRequest, CreateErrorResponse(400, string(ErrorTypeInvalidRequest), state.selectErr.Error()))
+			return
+		}
+		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "no_providers", state.selectErr.Error()))
+		return
+	}
+
+	// All providers failed
+	logger.Error("All providers failed for model",
+		"model", modelName)
+	if state.attemptNumber > 0 {
+		c.Header(attemptsHeader, strconv.Itoa(state.attemptNumber))
+		c.Header(failoverPathHeader, c.GetString(failoverPathKey))
+	}
+
+	// The request itself could not be expressed for any provider
+	if state.invalidAttempts > 0 && state.invalidAttempts == state.attemptNumber {
+		c.JSON(http.StatusBadRequest, CreateErrorResponse(400, string(ErrorTypeInvalidRequest), state.lastError.Message))
+		return
+	}
+
+	if state.lastError != nil {
+		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
+			"All providers failed: "+state.lastError.Message))
+	} else {
+		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
+			"All providers failed to process the request"))
+	}
+}
+
+// attemptState tracks the attempts made for one client request across fallback aliases
+type attemptState struct {
+	attemptNumber   int
+	invalidAttempts int // Attempts whose provider format could not express the request
+	failures        int // Failed attempts
+	limitedFailures int // Failed attempts rejected by rate limits or client-side limits
+	lastError       *ProxyError
+	selectErr       error               // Why no providers could be selected for the requested model
+	tried           map[string]struct{} // Provider/model combinations already tried
+	inputTokens     int                 // Estimated prompt size, reserved against tokens per minute limits
+}
+
+// record tracks a failed attempt
+func (s *attemptState) record(proxyErr *ProxyError) {
+	s.lastError = proxyErr
+	if proxyErr == nil {
+		return
+	}
+	s.failures++
+	switch proxyErr.Type {
+	case ErrorTypeInvalidRequest:
+		s.invalidAttempts++
+	case ErrorTypeRateLimit:
+		s.limitedFailures++
+	}
+}
+
+// tryChoices attempts the request with each provider choice in order, reporting whether it was served
+func (h *Handler) tryChoices(c *gin.Context, requestBody map[string]interface{}, providerChoices []*router.ProviderChoice,
+	headers map[string]string, isStreaming bool, modelName string, format responseFormat, state *attemptState) bool {
+	// Drop duplicate provider/model combinations, including those tried for an earlier alias
+	var candidates []*router.ProviderChoice
 	for _, choice := range providerChoices {
 		key := choice.Provider.Name + "::" + choice.ActualModel
-		if _, seen := attemptedCombos[key]; seen {
+		if _, seen := state.tried[key]; seen {
 			logger.Debug("Skipping duplicate provider/model combination",
 				"provider", choice.Provider.Name,
 				"model", choice.ActualModel)
 			continue
 		}
-		attemptedCombos[key] = struct{}{}
+		state.tried[key] = struct{}{}
 
-		if stats := h.errorTracker.GetModelData(choice.Provider.Name, choice.ActualModel); stats != nil &&
-			stats.TotalRequests >= 10 && stats.ErrorRate >= 0.5 {
-			logger.Warn("Skipping provider/model due to high failure rate",
-				"provider", choice.Provider.Name,
-				"model", choice.ActualModel,
-				"errorRate", stats.ErrorRate,
-				"totalRequests", stats.TotalRequests)
-			continue
+		candidates = append(candidates, choice)
+	}
+
+	// bodyFor encodes the request with the actual model name for a provider
+	bodyFor := func(choice *router.ProviderChoice) ([]byte, error) {
+		body := requestBody
+		if len(choice.Missing) > 0 {
+			body = degradeRequest(requestBody, choice)
 		}
+		body["model"] = choice.ActualModel
+		return json.Marshal(body)
+	}
 
-		attemptNumber++
+	// Try each provider in order
+	var saturated []*router.ProviderChoice // Choices skipped because their client-side limits were reached
 
-		// Update request body with the actual model name for this provider
-		requestBody["model"] = choice.ActualModel
-		updatedBody, err := json.Marshal(requestBody)
-		if err != nil {
-			continue
+	// allow checks the circuit breaker right before an attempt, since a half-open breaker admits a single probe
+	allow := func(choice *router.ProviderChoice) bool {
+		if h.errorTracker.Breaker().Allow(choice.Provider.Name, choice.ActualModel) {
+			return true
 		}
+		logger.Warn("Skipping provider/model with open circuit breaker",
+			"provider", choice.Provider.Name,
+			"model", choice.ActualModel)
+		return false
+	}
+
+	// send makes one attempt with capacity already reserved, reporting whether the request was served
+	send := func(choice *router.ProviderChoice, body []byte, release func()) bool {
+		defer release()
+		state.attemptNumber++
 
 		logger.Debug("Trying provider for model",
 			"provider", choice.Provider.Name,
@@ -141,59 +287,148 @@ func (h *Handler) HandleMessages(c *gin.Context) {
 		// Make the request
 		startTime := time.Now()
 
+		var success bool
+		var proxyErr *ProxyError
 		if isStreaming {
-			// Handle streaming request
-			success := h.handleStreamingRequest(c, choice.Provider, updatedBody, headers, choice, startTime, attemptNumber, modelName)
-			if success {
-				return // Success, response already sent
-			}
-			// Failed, try next provider
+			success, proxyErr = h.handleStreamingRequest(c, choice.Provider, body, headers, choice, startTime, state.attemptNumber, modelName, format, nil)
 		} else {
-			// Handle non-streaming request
-			success, proxyErr := h.handleNonStreamingRequest(c, choice.Provider, updatedBody, headers, choice, startTime, attemptNumber, modelName)
-			if success {
-				return // Success, response already sent
+			success, proxyErr = h.handleNonStreamingRequest(c, choice.Provider, body, headers, choice, startTime, state.attemptNumber, modelName, format)
+		}
+		if success {
+			return true
+		}
+		addFailover(c, choice)
+		state.record(proxyErr)
+		return false
+	}
+
+	for i := 0; i < len(candidates); i++ {
+		choice := candidates[i]
+
+		updatedBody, err := bodyFor(choice)
+		if err != nil {
+			continue
+		}
+
+		// Leave choices at their client-side limits for last rather than sending requests bound to be rejected
+		release, ok := choice.Provider.TryAcquire(choice.Model, state.inputTokens)
+		if !ok {
+			logger.Debug("Provider/model at its client-side limits, trying the next choice",
+				"provider", choice.Provider.Name,
+				"model", choice.ActualModel)
+			saturated = append(saturated, choice)
+			continue
+		}
+		if !allow(choice) {
+			release()
+			continue
+		}
+
+		if isStreaming && choice.Model.Hedge && i+1 < len(candidates) {
+			// Race the next choice if this one is slow to start streaming
+			backupChoice := candidates[i+1]
+			backupBody, err := bodyFor(backupChoice)
+			if err == nil {
+				state.attemptNumber++
+				primary := &streamAttempt{choice: choice, body: updatedBody, attemptNumber: state.attemptNumber, inputTokens: state.inputTokens}
+				backup := &streamAttempt{choice: backupChoice, body: backupBody, attemptNumber: state.attemptNumber + 1, inputTokens: state.inputTokens}
+				success, proxyErr, hedged := h.hedgeStream(c, primary, backup, headers, modelName, format)
+				release()
+				if success {
+					return true
+				}
+				addFailover(c, choice)
+				if hedged {
+					// Both choices have been tried
+					i++
+					state.attemptNumber++
+					addFailover(c, backupChoice)
+				}
+				state.record(proxyErr)
+				continue
 			}
-			lastError = proxyErr
+		}
+
+		if send(choice, updatedBody, release) {
+			return true
 		}
 	}
 
-	// All providers failed
-	logger.Error("All providers failed for model",
-		"model", modelName)
+	// Every choice with spare capacity failed; queue for the saturated ones in order
+	for _, choice := range saturated {
+		updatedBody, err := bodyFor(choice)
+		if err != nil {
+			continue
+		}
 
-	if lastError != nil {
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
-			"All providers failed: "+lastError.Message))
-	} else {
-		c.JSON(http.StatusBadGateway, CreateErrorResponse(502, "all_providers_failed",
-			"All providers failed to process the request"))
+		release, err := choice.Provider.Acquire(c.Request.Context(), choice.Model, state.inputTokens)
+		if err != nil {
+			proxyErr := LimitError(err, choice.Provider.Name)
+			LogError(proxyErr)
+			state.record(proxyErr)
+			continue
+		}
+		if !allow(choice) {
+			release()
+			continue
+		}
+
+		if send(choice, updatedBody, release) {
+			return true
+		}
 	}
+
+	return false
+}
+
+// recordRateLimit puts a provider/model into cooldown when its response advertises a
+// rate limit reset, so the selector tries other choices first until then
+func (h *Handler) recordRateLimit(providerName, modelName string, resp *http.Response) {
+	now := time.Now()
+	delay := retry.RateLimitDelay(resp.StatusCode, resp.Header, now)
+	if delay <= 0 {
+		return
+	}
+	h.tracker.SetCooldown(providerName, modelName, now.Add(delay))
+	logger.Info("Provider rate limited, cooling down",
+		"provider", providerName,
+		"model", modelName,
+		"statusCode", resp.StatusCode,
+		"until", now.Add(delay).Format(time.RFC3339))
 }
 
 // handleNonStreamingRequest handles a non-streaming request to a provider
 func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provider, body []byte,
-	headers map[string]string, choice *router.ProviderChoice, startTime time.Time, attemptNumber int, modelName string) (bool, *ProxyError) {
+	headers map[string]string, choice *router.ProviderChoice, startTime time.Time, attemptNumber int, modelName string, format responseFormat) (bool, *ProxyError) {
 
 	// Log request if request logger is enabled
 	if h.requestLogger != nil {
-		h.requestLogger.LogRequest(prov.Name, modelName, "POST", "/v1/messages", headers, body, attemptNumber, false)
+		h.requestLogger.LogRequest(prov.Name, modelName, "POST", "/v1/messages", headers, body, attemptNumber, false, routingRule(c))
 	}
 
+	// Only the total timeout applies; the response arrives in one piece
+	deadlines := newRequestDeadlines(c.Request.Context(), prov.Client.Timeouts(), false)
+	defer deadlines.stop()
+
 	var resp *http.Response
 	var err error
 	if h.retrySameProvider {
-		resp, err = prov.Client.ProxyRequestWithRetry(c.Request.Context(), "POST", "/v1/messages", body, headers, h.retryConfig, prov.Name)
+		resp, err = prov.Client.ProxyRequestWithRetry(deadlines.ctx, "POST", "/v1/messages", body, headers, h.retryConfig, prov.Name)
 	} else {
-		resp, err = prov.Client.ProxyRequest(c.Request.Context(), "POST", "/v1/messages", body, headers)
+		resp, err = prov.Client.ProxyRequest(deadlines.ctx, "POST", "/v1/messages", body, headers)
 	}
 	duration := time.Since(startTime)
 
 	// Check for network errors
 	if err != nil {
+		if timeoutErr := deadlines.err(); timeoutErr != nil {
+			err = timeoutErr
+		}
 		proxyErr := ClassifyError(0, err, prov.Name)
 		LogError(proxyErr)
-		h.errorTracker.RecordError(prov.Name, choice.ActualModel, 0)
+		if proxyErr.Type != ErrorTypeInvalidRequest {
+			h.errorTracker.RecordError(prov.Name, choice.ActualModel, 0)
+		}
 
 		// Log failed response
 		if h.requestLogger != nil {
@@ -203,6 +438,7 @@ func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provi
 		return false, proxyErr
 	}
 	defer resp.Body.Close()
+	h.recordRateLimit(prov.Name, choice.ActualModel, resp)
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -222,6 +458,9 @@ func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provi
 	// Success! Read and return response
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
+		if timeoutErr := deadlines.err(); timeoutErr != nil {
+			err = timeoutErr
+		}
 		logger.Error("Error reading response from provider",
 			"provider", prov.Name,
 			"error", err.Error())
@@ -234,39 +473,36 @@ func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provi
 		return false, ClassifyError(0, err, prov.Name)
 	}
 
-	// Convert OpenAI response to Anthropic format if needed
-	finalResponseBody := responseBody
-	if prov.Type == transform.ProviderTypeOpenAI {
-		convertedBody, err := transform.OpenAIToAnthropicResponse(responseBody, modelName)
-		if err != nil {
-			logger.Error("Failed to convert OpenAI response to Anthropic format",
-				"provider", prov.Name,
-				"error", err.Error())
-
-			// Log failed response
-			if h.requestLogger != nil {
-				h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, nil, nil, duration, 0, attemptNumber, false, err.Error(), false)
-			}
+	// Convert the provider response to Anthropic format and extract its usage
+	finalResponseBody, anthropicUsage, err := prov.Client.DecodeResponse(responseBody, responseModel(c, choice, modelName))
+	if err != nil {
+		logger.Error("Failed to convert provider response to Anthropic format",
+			"provider", prov.Name,
+			"error", err.Error())
 
-			return false, ClassifyError(0, err, prov.Name)
+		// Log failed response
+		if h.requestLogger != nil {
+			h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, nil, nil, duration, 0, attemptNumber, false, err.Error(), false)
 		}
-		finalResponseBody = convertedBody
+
+		return false, ClassifyError(0, err, prov.Name)
 	}
 
-	// Extract token count and record metrics
-	inputTokens := 0
-	outputTokens := 0
-	totalTokens := 0
-	var responseData map[string]interface{}
-	if err := json.Unmarshal(finalResponseBody, &responseData); err == nil {
-		inputTokens, outputTokens, totalTokens = extractDetailedTokenCount(responseData)
-		h.tracker.RecordRequest(prov.Name, choice.ActualModel, totalTokens, duration)
-		logger.Debug("Request succeeded with provider",
-			"provider", prov.Name,
-			"tokens", totalTokens,
-			"duration", duration.Seconds(),
-			"tps", float64(totalTokens)/duration.Seconds())
+	// Record metrics
+	usage := analytics.TokenUsage{
+		InputTokens:              anthropicUsage.InputTokens,
+		OutputTokens:             anthropicUsage.OutputTokens,
+		CacheCreationInputTokens: anthropicUsage.CacheCreationInputTokens,
+		CacheReadInputTokens:     anthropicUsage.CacheReadInputTokens,
 	}
+	totalTokens := usage.InputTokens + usage.OutputTokens
+	h.tracker.RecordRequest(prov.Name, choice.ActualModel, totalTokens, duration)
+	prov.RecordTokens(choice.Model, usage.OutputTokens)
+	logger.Debug("Request succeeded with provider",
+		"provider", prov.Name,
+		"tokens", totalTokens,
+		"duration", duration.Seconds(),
+		"tps", float64(totalTokens)/duration.Seconds())
 
 	// Record success
 	h.errorTracker.RecordSuccess(prov.Name, choice.ActualModel)
@@ -280,7 +516,7 @@ func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provi
 			if tokenID > 0 {
 				tokenIDPtr = &tokenID
 			}
-			h.analyticsService.RecordRequest(userID, tokenIDPtr, modelName, prov.Name, inputTokens, outputTokens, duration, "success", "")
+			h.analyticsService.RecordRequest(userID, tokenIDPtr, modelName, prov.Name, usage, duration, "success", "", routingRule(c))
 		}
 	}
 
@@ -290,14 +526,27 @@ func (h *Handler) handleNonStreamingRequest(c *gin.Context, prov *provider.Provi
 		h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, respHeaders, finalResponseBody, duration, totalTokens, attemptNumber, true, "", false)
 	}
 
-	// Copy response headers
+	// Convert to an OpenAI chat completion for /v1/chat/completions clients
+	if format.openAI {
+		convertedBody, err := transform.AnthropicToOpenAIResponse(finalResponseBody, responseModel(c, choice, modelName))
+		if err != nil {
+			logger.Error("Failed to convert response to OpenAI format",
+				"provider", prov.Name,
+				"error", err.Error())
+			return false, ClassifyError(0, err, prov.Name)
+		}
+		finalResponseBody = convertedBody
+	}
+
+	// Copy response headers (the body may have been converted, so its length is set by gin)
 	for key, values := range resp.Header {
-		if len(values) > 0 {
+		if len(values) > 0 && key != "Content-Length" {
 			c.Header(key, values[0])
 		}
 	}
 
 	// Send response
+	setRoutingHeaders(c, choice, attemptNumber)
 	c.Data(resp.StatusCode, "application/json", finalResponseBody)
 	return true, nil
 }
@@ -315,33 +564,6 @@ func extractTokenCount(response map[string]interface{}) int {
 	return 0
 }
 
-// extractDetailedTokenCount extracts detailed token counts from response
-func extractDetailedTokenCount(response map[string]interface{}) (inputTokens, outputTokens, totalTokens int) {
-	if usage, ok := response["usage"].(map[string]interface{}); ok {
-		// Anthropic format
-		if input, ok := usage["input_tokens"].(float64); ok {
-			inputTokens = int(input)
-		}
-		if output, ok := usage["output_tokens"].(float64); ok {
-			outputTokens = int(output)
-		}
-		// OpenAI format fallback
-		if prompt, ok := usage["prompt_tokens"].(float64); ok {
-			inputTokens = int(prompt)
-		}
-		if completion, ok := usage["completion_tokens"].(float64); ok {
-			outputTokens = int(completion)
-		}
-		// Calculate total
-		if total, ok := usage["total_tokens"].(float64); ok {
-			totalTokens = int(total)
-		} else {
-			totalTokens = inputTokens + outputTokens
-		}
-	}
-	return
-}
-
 // extractHeaders converts http.Header to a simple map[string]string
 func extractHeaders(headers http.Header) map[string]string {
 	result := make(map[string]string, len(headers))
@@ -33,14 +33,39 @@ func (h *HealthHandler) HandleHealth(c *gin.Context) {
 	healthyCount := 0
 	providerStatus := make(map[string]interface{})
 
+	// Group circuit breakers by provider
+	breakers := make(map[string]map[string]interface{})
+	openBreakers := make(map[string]int)
+	for _, breaker := range h.errorTracker.Breaker().GetAll() {
+		if breakers[breaker.ProviderName] == nil {
+			breakers[breaker.ProviderName] = make(map[string]interface{})
+		}
+		entry := map[string]interface{}{
+			"state":    breaker.State,
+			"requests": breaker.Requests,
+			"errors":   breaker.Errors,
+		}
+		if !breaker.OpenedAt.IsZero() {
+			entry["opened_at"] = breaker.OpenedAt
+		}
+		breakers[breaker.ProviderName][breaker.ModelName] = entry
+		if breaker.State == metrics.BreakerOpen {
+			openBreakers[breaker.ProviderName]++
+		}
+	}
+
 	for _, prov := range providers {
 		errorRate := h.errorTracker.GetErrorRate(prov.Name)
-		isHealthy := errorRate < 0.5 // Less than 50% error rate
+		// A provider is unhealthy once every model with traffic has an open circuit breaker
+		isHealthy := openBreakers[prov.Name] == 0 || openBreakers[prov.Name] < len(breakers[prov.Name])
 
 		status := map[string]interface{}{
 			"healthy":    isHealthy,
 			"error_rate": errorRate,
 		}
+		if len(breakers[prov.Name]) > 0 {
+			status["circuit_breakers"] = breakers[prov.Name]
+		}
 
 		if isHealthy {
 			healthyCount++
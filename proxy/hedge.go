@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"anthropic-proxy/logger"
+	"anthropic-proxy/router"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errHedgeLost ends an attempt whose hedged rival reached the client first
+var errHedgeLost = errors.New("lost hedged race")
+
+// hedgeRace decides which of the concurrent attempts for one streaming request
+// reaches the client: the first to commit its stream wins and the others are cancelled
+type hedgeRace struct {
+	mu        sync.Mutex
+	client    *gin.Context // The request's context; only the winner writes to it
+	lanes     []*hedgeLane
+	winner    *hedgeLane
+	committed chan struct{} // Closed once an attempt has won
+}
+
+// hedgeLane is one attempt in a hedgeRace. It runs on its own copy of the request
+// context, with separate Keys and no response writer, until it wins the race.
+type hedgeLane struct {
+	race   *hedgeRace
+	c      *gin.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newHedgeRace(c *gin.Context) *hedgeRace {
+	return &hedgeRace{client: c, committed: make(chan struct{})}
+}
+
+// join adds an attempt to the race
+func (r *hedgeRace) join() *hedgeLane {
+	ctx, cancel := context.WithCancel(r.client.Request.Context())
+	lane := &hedgeLane{race: r, c: r.client.Copy(), ctx: ctx, cancel: cancel}
+
+	r.mu.Lock()
+	r.lanes = append(r.lanes, lane)
+	r.mu.Unlock()
+	return lane
+}
+
+// commit claims the client for this attempt, cancelling the others; false if another attempt won
+func (l *hedgeLane) commit() bool {
+	if l == nil {
+		return true
+	}
+	r := l.race
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.winner != nil {
+		return r.winner == l
+	}
+	r.winner = l
+	close(r.committed)
+	for _, lane := range r.lanes {
+		if lane != l {
+			lane.cancel()
+		}
+	}
+	return true
+}
+
+// lost reports whether another attempt won the race
+func (l *hedgeLane) lost() bool {
+	if l == nil {
+		return false
+	}
+	l.race.mu.Lock()
+	defer l.race.mu.Unlock()
+	return l.race.winner != nil && l.race.winner != l
+}
+
+// context returns the attempt's context, or fallback outside a race
+func (l *hedgeLane) context(fallback context.Context) context.Context {
+	if l == nil {
+		return fallback
+	}
+	return l.ctx
+}
+
+// streamAttempt is one provider choice prepared for a streaming request
+type streamAttempt struct {
+	choice        *router.ProviderChoice
+	body          []byte
+	attemptNumber int
+	inputTokens   int // Estimated prompt size, reserved against tokens per minute limits
+}
+
+// streamResult is the outcome of a streaming attempt
+type streamResult struct {
+	attempt *streamAttempt
+	success bool
+	err     *ProxyError
+}
+
+// hedgeStream streams from primary and, if it has not committed within the hedge
+// delay, races backup against it. It reports whether the request was served, the
+// last error, and whether backup was used.
+func (h *Handler) hedgeStream(c *gin.Context, primary, backup *streamAttempt, headers map[string]string,
+	modelName string, format responseFormat) (bool, *ProxyError, bool) {
+
+	race := newHedgeRace(c)
+	results := make(chan streamResult, 2)
+	run := func(attempt *streamAttempt, lane *hedgeLane) {
+		success, proxyErr := h.handleStreamingRequest(lane.c, attempt.choice.Provider, attempt.body, headers, attempt.choice,
+			time.Now(), attempt.attemptNumber, modelName, format, lane)
+		results <- streamResult{attempt: attempt, success: success, err: proxyErr}
+	}
+
+	primaryLane := race.join()
+	defer primaryLane.cancel()
+	go run(primary, primaryLane)
+
+	delay := primary.choice.Model.HedgeAfter(h.tracker.GetTTFT(primary.choice.Provider.Name, primary.choice.ActualModel))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case result := <-results:
+		// The primary finished before the hedge was needed
+		return result.success, result.err, false
+	case <-race.committed:
+		result := <-results
+		return result.success, result.err, false
+	case <-timer.C:
+	}
+
+	// Only hedge into a choice with spare capacity and a closed circuit
+	backupRelease, ok := backup.choice.Provider.TryAcquire(backup.choice.Model, backup.inputTokens)
+	if ok && !h.errorTracker.Breaker().Allow(backup.choice.Provider.Name, backup.choice.ActualModel) {
+		backupRelease()
+		ok = false
+	}
+	if !ok {
+		result := <-results
+		return result.success, result.err, false
+	}
+	defer backupRelease()
+
+	logger.Info("Hedging slow provider",
+		"provider", primary.choice.Provider.Name,
+		"model", primary.choice.ActualModel,
+		"hedgeProvider", backup.choice.Provider.Name,
+		"hedgeModel", backup.choice.ActualModel,
+		"after", delay.String())
+
+	backupLane := race.join()
+	defer backupLane.cancel()
+	go run(backup, backupLane)
+
+	// Wait for both attempts so neither touches the response after we return
+	var success bool
+	var lastError *ProxyError
+	for i := 0; i < 2; i++ {
+		result := <-results
+		if result.success {
+			success = true
+		} else if result.err != nil {
+			lastError = result.err
+		}
+	}
+
+	if race.winner != nil {
+		lanes := map[*hedgeLane]*streamAttempt{primaryLane: primary, backupLane: backup}
+		for lane, attempt := range lanes {
+			h.tracker.RecordHedge(attempt.choice.Provider.Name, attempt.choice.ActualModel, lane == race.winner)
+			if lane != race.winner {
+				// The cancelled attempt has no outcome; free the probe if its breaker let it through as one
+				h.errorTracker.Breaker().ReleaseProbe(attempt.choice.Provider.Name, attempt.choice.ActualModel)
+			}
+		}
+	}
+
+	return success, lastError, true
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"anthropic-proxy/provider"
+	"anthropic-proxy/router"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHedgeLanesOnlyWinnerWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+	race := newHedgeRace(c)
+	primary, backup := race.join(), race.join()
+	defer primary.cancel()
+	defer backup.cancel()
+
+	// Lanes keep their values to themselves
+	primary.c.Set("lane", "primary")
+	if _, exists := c.Get("lane"); exists {
+		t.Error("lane value leaked into the request context")
+	}
+
+	winner := &streamClient{c: backup.c, flusher: backup.c.Writer, lane: backup, choice: testChoice("backup"), attemptNumber: 2}
+	loser := &streamClient{c: primary.c, flusher: primary.c.Writer, lane: primary, choice: testChoice("primary"), attemptNumber: 1}
+
+	if !winner.start() {
+		t.Fatal("first lane to commit did not win")
+	}
+	if loser.start() {
+		t.Fatal("second lane to commit also won")
+	}
+	if primary.ctx.Err() == nil {
+		t.Error("losing lane was not cancelled")
+	}
+
+	if err := winner.writeLine([]byte("data: {}\n\n")); err != nil {
+		t.Fatalf("winner write: %v", err)
+	}
+	if got := recorder.Body.String(); got != "data: {}\n\n" {
+		t.Errorf("response body = %q", got)
+	}
+	if got := recorder.Header().Get(servedByHeader); got != "backup" {
+		t.Errorf("%s = %q, want backup", servedByHeader, got)
+	}
+}
+
+// testChoice returns a choice for a provider that is never called
+func testChoice(providerName string) *router.ProviderChoice {
+	return &router.ProviderChoice{Provider: &provider.Provider{Name: providerName}, ActualModel: "model"}
+}
@@ -1,12 +1,23 @@
 package proxy
 
 import (
+	"anthropic-proxy/auth"
+	"anthropic-proxy/config"
 	"anthropic-proxy/model"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultModelsLimit = 20   // Page size when the client does not ask for one
+	maxModelsLimit     = 1000 // Largest page size the Anthropic models API accepts
+	modelsOwner        = "anthropic-proxy"
+)
+
 // ModelsHandler handles /v1/models requests
 type ModelsHandler struct {
 	registry *model.Registry
@@ -19,40 +30,173 @@ func NewModelsHandler(registry *model.Registry) *ModelsHandler {
 	}
 }
 
-// HandleListModels handles GET /v1/models
+// HandleListModels handles GET /v1/models in the Anthropic models API format, paged
+// with limit, after_id and before_id. Each entry is a model name or alias clients can
+// request, described by the models serving it; models the caller's token may not use
+// are left out. OpenAI clients find the fields they expect as well.
 func (h *ModelsHandler) HandleListModels(c *gin.Context) {
-	models := h.registry.GetAll()
+	limit := defaultModelsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxModelsLimit {
+			c.JSON(http.StatusBadRequest, CreateErrorResponse(400, string(ErrorTypeInvalidRequest),
+				"limit must be between 1 and "+strconv.Itoa(maxModelsLimit)))
+			return
+		}
+		limit = parsed
+	}
 
-	// Convert to Anthropic-compatible format
-	var modelList []map[string]interface{}
-	for _, m := range models {
-		modelInfo := map[string]interface{}{
-			"id":      m.Name,
-			"object":  "model",
-			"created": 0, // Could add timestamp if needed
-			"owned_by": m.Provider,
+	ids := h.registry.ModelIDs()
+
+	// Page around the cursor; an unknown cursor (e.g. a model removed meanwhile) gives an empty page
+	var start, end int
+	var hasMore bool
+	if afterID := c.Query("after_id"); afterID != "" {
+		start = indexOf(ids, afterID) + 1
+		if start == 0 {
+			start = len(ids)
 		}
+		end = min(start+limit, len(ids))
+		hasMore = end < len(ids)
+	} else if beforeID := c.Query("before_id"); beforeID != "" {
+		end = max(indexOf(ids, beforeID), 0)
+		start = max(end-limit, 0)
+		hasMore = start > 0
+	} else {
+		end = min(limit, len(ids))
+		hasMore = end < len(ids)
+	}
+	page := ids[start:end]
+
+	data := make([]gin.H, 0, len(page))
+	for _, id := range page {
+		data = append(data, h.describeModel(c, id, h.registry.FindMatching(id)))
+	}
+
+	response := gin.H{
+		"object":   "list",
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": nil,
+		"last_id":  nil,
+	}
+	if len(page) > 0 {
+		response["first_id"] = page[0]
+		response["last_id"] = page[len(page)-1]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleGetModel handles GET /v1/models/*id; IDs may contain slashes (e.g. "anthropic/claude-sonnet-4")
+func (h *ModelsHandler) HandleGetModel(c *gin.Context) {
+	id := strings.TrimPrefix(c.Param("id"), "/")
+
+	models := h.registry.FindMatching(id)
+	if len(models) == 0 {
+		c.JSON(http.StatusNotFound, CreateErrorResponse(404, "not_found_error", "model not found: "+id))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.describeModel(c, id, models))
+}
 
-		// Add alias if present
-		if m.Alias != "" {
-			modelInfo["alias"] = m.Alias
+// describeModel builds the models API entry for a requested name from the models serving it.
+// Context window and output limit are the largest known; capabilities are those at least
+// one model has. Providers and the routing strategy are only shown to admins.
+func (h *ModelsHandler) describeModel(c *gin.Context, id string, models []*config.Model) gin.H {
+	displayName := id
+	var createdAt time.Time
+	contextWindow := 0
+	maxOutputTokens := 0
+	unlimitedOutput := false
+	thinking := false
+	for _, m := range models {
+		if displayName == id && m.DisplayName != "" {
+			displayName = m.DisplayName
+		}
+		if m.CreatedAt.After(createdAt) {
+			createdAt = m.CreatedAt
+		}
+		if m.Context > contextWindow {
+			contextWindow = m.Context
+		}
+		if m.MaxOutputTokens == 0 {
+			unlimitedOutput = true
+		} else if m.MaxOutputTokens > maxOutputTokens {
+			maxOutputTokens = m.MaxOutputTokens
 		}
+		if m.Thinking {
+			thinking = true
+		}
+	}
+	if createdAt.IsZero() {
+		createdAt = time.Unix(0, 0)
+	}
 
-		// Add context window
-		if m.Context > 0 {
-			modelInfo["context_window"] = m.Context
+	capabilities := []string{}
+	for _, capability := range []string{config.CapabilityTools, config.CapabilityVision, config.CapabilityPDF,
+		config.CapabilityThinking, config.CapabilityPromptCaching} {
+		for _, m := range models {
+			if m.HasCapability(capability) {
+				capabilities = append(capabilities, capability)
+				break
+			}
 		}
+	}
 
-		// Add weight
-		modelInfo["weight"] = m.GetWeight()
+	entry := gin.H{
+		"type":         "model",
+		"id":           id,
+		"display_name": displayName,
+		"created_at":   createdAt.UTC().Format(time.RFC3339),
+		"capabilities": capabilities,
+		"thinking":     thinking,
 
-		modelList = append(modelList, modelInfo)
+		// OpenAI models API fields
+		"object":   "model",
+		"created":  createdAt.Unix(),
+		"owned_by": modelsOwner,
+	}
+	if contextWindow > 0 {
+		entry["context_window"] = contextWindow
 	}
+	if maxOutputTokens > 0 && !unlimitedOutput {
+		entry["max_output_tokens"] = maxOutputTokens
+	}
+
+	if auth.IsAdmin(c) {
+		providers := make([]gin.H, 0, len(models))
+		for _, m := range models {
+			provider := gin.H{
+				"provider": m.Provider,
+				"model":    m.Name,
+				"weight":   m.GetWeight(),
+			}
+			if m.Context > 0 {
+				provider["context_window"] = m.Context
+			}
+			if m.Discovered {
+				provider["discovered"] = true
+			}
+			providers = append(providers, provider)
+		}
+		entry["providers"] = providers
 
-	response := map[string]interface{}{
-		"object": "list",
-		"data":   modelList,
+		if _, alias, ok := h.registry.GetAlias(id); ok {
+			entry["strategy"] = alias.GetStrategy()
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	return entry
+}
+
+// indexOf returns the position of value in values, or -1
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,283 @@
+package proxy
+
+import (
+	"anthropic-proxy/auth"
+	"anthropic-proxy/config"
+	"anthropic-proxy/logger"
+	"anthropic-proxy/router"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys for routing decisions
+const (
+	routingRuleKey    = "routing_rule"    // Name of the routing rule that matched
+	downgradedFromKey = "downgraded_from" // Requested model when a fallback alias serves the request
+	failoverPathKey   = "failover_path"   // Provider/model combinations that failed before the current attempt
+)
+
+// Request headers that override routing for a single request
+const (
+	overrideHeaderPrefix   = "X-Proxy-"
+	providerHeader         = "X-Proxy-Provider"          // Only use this provider
+	excludeProvidersHeader = "X-Proxy-Exclude-Providers" // Comma-separated providers to skip
+	strategyHeader         = "X-Proxy-Strategy"          // Routing strategy instead of the alias's
+	noFallbackHeader       = "X-Proxy-No-Fallback"       // Try only the first choice
+)
+
+// Response headers that report how a request was routed
+const (
+	servedByHeader       = "X-Proxy-Served-By"       // Provider that served the request
+	attemptsHeader       = "X-Proxy-Attempts"        // Attempts made, including the one that served it
+	failoverPathHeader   = "X-Proxy-Failover-Path"   // Provider/model combinations tried, in order
+	downgradedFromHeader = "X-Proxy-Downgraded-From" // Requested model when a fallback alias served the request
+)
+
+// errOverridesNotAllowed rejects routing override headers from tokens without the permission
+var errOverridesNotAllowed = errors.New("this API token is not allowed to use the X-Proxy-* routing override headers")
+
+// errOverrideNotAllowedByRule rejects a pinned provider the matching routing rule does not allow
+var errOverrideNotAllowedByRule = errors.New("routing override not allowed")
+
+// routeRequest describes what an Anthropic messages request needs from the models it is
+// routed to, and who sent it
+func routeRequest(c *gin.Context, modelName string, requestBody map[string]interface{}) router.Request {
+	route := router.Request{
+		Model:       modelName,
+		InputTokens: estimatePromptTokens(requestBody),
+		TokenName:   auth.GetTokenName(c),
+		Headers:     c.Request.Header,
+		System:      systemText(requestBody["system"]),
+	}
+	route.UserID, _ = auth.GetUserID(c)
+
+	// Check if thinking is enabled in the request
+	if thinking, ok := requestBody["thinking"].(map[string]interface{}); ok {
+		if thinkingType, ok := thinking["type"].(string); ok && thinkingType == "enabled" {
+			route.Thinking = true
+		}
+	}
+
+	if value, ok := requestBody["max_tokens"].(float64); ok {
+		route.MaxTokens = int(value)
+	}
+
+	if tools, ok := requestBody["tools"].([]interface{}); ok && len(tools) > 0 {
+		route.Tools = true
+	}
+
+	scanContent(requestBody["system"], &route)
+	scanContent(requestBody["tools"], &route)
+	if messages, ok := requestBody["messages"].([]interface{}); ok {
+		for _, message := range messages {
+			if msg, ok := message.(map[string]interface{}); ok {
+				scanContent(msg["content"], &route)
+			}
+		}
+	}
+
+	return route
+}
+
+// applyRoutingOverrides applies the client's routing override headers on top of any
+// matching routing rule. Database tokens need the routing overrides permission.
+func applyRoutingOverrides(c *gin.Context, route *router.Request) error {
+	pinned := strings.TrimSpace(c.GetHeader(providerHeader))
+	excluded := splitList(c.GetHeader(excludeProvidersHeader))
+	strategy := strings.TrimSpace(c.GetHeader(strategyHeader))
+	noFallback := strings.TrimSpace(c.GetHeader(noFallbackHeader))
+	if pinned == "" && len(excluded) == 0 && strategy == "" && noFallback == "" {
+		return nil
+	}
+
+	if !auth.CanOverrideRouting(c) {
+		return errOverridesNotAllowed
+	}
+
+	// Overrides narrow what a routing rule allows; they never widen it
+	if pinned != "" {
+		if len(route.Providers) > 0 && indexOf(route.Providers, pinned) < 0 {
+			return fmt.Errorf("%w: provider %s is not allowed by routing rule %s", errOverrideNotAllowedByRule, pinned, route.Rule)
+		}
+		route.Providers = []string{pinned}
+	}
+	route.ExcludeProviders = append(route.ExcludeProviders, excluded...)
+	if strategy != "" {
+		if !config.IsStrategy(strategy) {
+			return fmt.Errorf("unknown strategy '%s' in %s", strategy, strategyHeader)
+		}
+		route.Strategy = strategy
+	}
+	if noFallback != "" {
+		value, err := strconv.ParseBool(noFallback)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", noFallbackHeader)
+		}
+		route.NoFallback = value
+	}
+
+	logger.Debug("Applying routing override headers",
+		"model", route.Model,
+		"provider", pinned,
+		"excluded", excluded,
+		"strategy", strategy,
+		"noFallback", route.NoFallback)
+	return nil
+}
+
+// splitList splits a comma-separated header value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setRoutingHeaders reports the choice serving the request, the number of attempts, the
+// provider/model combinations tried on the way and, after a fallback to another alias, the
+// requested model; called right before a successful response is written
+func setRoutingHeaders(c *gin.Context, choice *router.ProviderChoice, attemptNumber int) {
+	c.Header(servedByHeader, choice.Provider.Name)
+	c.Header(attemptsHeader, strconv.Itoa(attemptNumber))
+	c.Header(failoverPathHeader, failoverPath(c, choice))
+	if from := c.GetString(downgradedFromKey); from != "" {
+		c.Header(downgradedFromHeader, from)
+	}
+}
+
+// addFailover records a choice that failed in the failover path
+func addFailover(c *gin.Context, choice *router.ProviderChoice) {
+	c.Set(failoverPathKey, failoverPath(c, choice))
+}
+
+// failoverPath returns the choices that failed so far followed by choice
+func failoverPath(c *gin.Context, choice *router.ProviderChoice) string {
+	path := choice.Provider.Name + "/" + choice.ActualModel
+	if failed := c.GetString(failoverPathKey); failed != "" {
+		path = failed + "," + path
+	}
+	return path
+}
+
+// systemText joins the text of a system prompt given as a string or text blocks
+func systemText(system interface{}) string {
+	switch value := system.(type) {
+	case string:
+		return value
+	case []interface{}:
+		var parts []string
+		for _, item := range value {
+			if block, ok := item.(map[string]interface{}); ok {
+				if text, ok := block["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// routingRule returns the name of the routing rule that matched the request, if any
+func routingRule(c *gin.Context) string {
+	return c.GetString(routingRuleKey)
+}
+
+// responseModel returns the model name to report in responses: the requested one, or
+// the model that actually served the request after a fallback to another alias
+func responseModel(c *gin.Context, choice *router.ProviderChoice, requested string) string {
+	if c.GetString(downgradedFromKey) != "" {
+		return choice.ActualModel
+	}
+	return requested
+}
+
+// scanContent marks the image, PDF and cache_control usage found in content blocks
+func scanContent(content interface{}, route *router.Request) {
+	switch value := content.(type) {
+	case []interface{}:
+		for _, item := range value {
+			scanContent(item, route)
+		}
+
+	case map[string]interface{}:
+		if _, ok := value["cache_control"]; ok {
+			route.PromptCaching = true
+		}
+		switch blockType, _ := value["type"].(string); blockType {
+		case "image":
+			route.Images = true
+		case "document":
+			if source, ok := value["source"].(map[string]interface{}); ok {
+				if mediaType, _ := source["media_type"].(string); mediaType == "application/pdf" {
+					route.PDFs = true
+				}
+			}
+		case "tool_result":
+			scanContent(value["content"], route)
+		}
+	}
+}
+
+// degradeRequest returns a copy of the request without what the chosen model does not
+// support: thinking is dropped, max_tokens is capped and cache_control is removed.
+// Tools, images and PDFs cannot be stripped and are sent as they are. Thinking is only
+// dropped for models with a capabilities list, since older configs never declared it.
+func degradeRequest(requestBody map[string]interface{}, choice *router.ProviderChoice) map[string]interface{} {
+	degraded := make(map[string]interface{}, len(requestBody))
+	for key, value := range requestBody {
+		degraded[key] = value
+	}
+
+	for _, capability := range choice.Missing {
+		switch capability {
+		case config.CapabilityThinking:
+			if len(choice.Model.Capabilities) > 0 {
+				delete(degraded, "thinking")
+			}
+		case router.MaxOutputTokens:
+			degraded["max_tokens"] = choice.Model.MaxOutputTokens
+		case config.CapabilityPromptCaching:
+			for _, key := range []string{"system", "messages", "tools"} {
+				if value, ok := degraded[key]; ok {
+					degraded[key] = withoutCacheControl(value)
+				}
+			}
+		}
+	}
+
+	logger.Debug("Degraded request for model",
+		"provider", choice.Provider.Name,
+		"model", choice.ActualModel,
+		"missing", choice.Missing)
+	return degraded
+}
+
+// withoutCacheControl returns a copy of a JSON value with every cache_control field removed
+func withoutCacheControl(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = withoutCacheControl(item)
+		}
+		return items
+
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if key != "cache_control" {
+				fields[key] = withoutCacheControl(field)
+			}
+		}
+		return fields
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"anthropic-proxy/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestApplyRoutingOverrides(t *testing.T) {
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		token         bool // Database token without the routing overrides permission
+		ruleProviders []string
+		want          router.Request
+		wantErr       error // Expected sentinel error
+		wantInvalid   bool  // Expect a validation error without a sentinel
+	}{
+		{name: "no headers", token: true, want: router.Request{}},
+		{
+			name:    "all overrides",
+			headers: map[string]string{providerHeader: " alpha ", excludeProvidersHeader: "beta, ,gamma", strategyHeader: "round-robin", noFallbackHeader: "true"},
+			want:    router.Request{Providers: []string{"alpha"}, ExcludeProviders: []string{"beta", "gamma"}, Strategy: "round-robin", NoFallback: true},
+		},
+		{
+			name:    "token without permission",
+			headers: map[string]string{providerHeader: "alpha"},
+			token:   true,
+			wantErr: errOverridesNotAllowed,
+		},
+		{
+			name:          "provider allowed by rule",
+			headers:       map[string]string{providerHeader: "beta"},
+			ruleProviders: []string{"alpha", "beta"},
+			want:          router.Request{Providers: []string{"beta"}},
+		},
+		{
+			name:          "provider outside rule",
+			headers:       map[string]string{providerHeader: "gamma"},
+			ruleProviders: []string{"alpha", "beta"},
+			wantErr:       errOverrideNotAllowedByRule,
+		},
+		{name: "unknown strategy", headers: map[string]string{strategyHeader: "fastest"}, wantInvalid: true},
+		{name: "invalid no-fallback", headers: map[string]string{noFallbackHeader: "maybe"}, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			if tt.token {
+				c.Set("token_id", uint(1))
+			}
+
+			route := router.Request{Providers: tt.ruleProviders}
+			err := applyRoutingOverrides(c, &route)
+			switch {
+			case tt.wantInvalid:
+				if err == nil {
+					t.Fatal("expected a validation error")
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatalf("applyRoutingOverrides: %v", err)
+			}
+
+			if !slices.Equal(route.Providers, tt.want.Providers) || !slices.Equal(route.ExcludeProviders, tt.want.ExcludeProviders) ||
+				route.Strategy != tt.want.Strategy || route.NoFallback != tt.want.NoFallback {
+				t.Errorf("route = %+v, want %+v", route, tt.want)
+			}
+		})
+	}
+}
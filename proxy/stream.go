@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"anthropic-proxy/analytics"
 	"anthropic-proxy/auth"
 	"anthropic-proxy/logger"
 	"anthropic-proxy/provider"
@@ -9,6 +10,8 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -17,22 +20,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// handleStreamingRequest handles a streaming SSE request
+// handleStreamingRequest handles a streaming SSE request. When hedging, lane is the
+// attempt's place in the race; an attempt that loses returns no error.
 func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider, body []byte,
-	headers map[string]string, choice *router.ProviderChoice, startTime time.Time, attemptNumber int, modelName string) bool {
+	headers map[string]string, choice *router.ProviderChoice, startTime time.Time, attemptNumber int, modelName string, format responseFormat, lane *hedgeLane) (bool, *ProxyError) {
 
 	// Log request if request logger is enabled
 	if h.requestLogger != nil {
-		h.requestLogger.LogRequest(prov.Name, modelName, "POST", "/v1/messages", headers, body, attemptNumber, true)
+		h.requestLogger.LogRequest(prov.Name, modelName, "POST", "/v1/messages", headers, body, attemptNumber, true, routingRule(c))
 	}
 
-	resp, err := prov.Client.StreamRequest(c.Request.Context(), "POST", "/v1/messages", body, headers)
+	// First-token, idle and total timeouts cancel the request; see requestDeadlines
+	deadlines := newRequestDeadlines(lane.context(c.Request.Context()), prov.Client.Timeouts(), true)
+	defer deadlines.stop()
+
+	resp, err := prov.Client.StreamRequest(deadlines.ctx, "POST", "/v1/messages", body, headers)
 
 	// Check for network errors
 	if err != nil {
+		if lane.lost() {
+			return false, nil
+		}
+		if timeoutErr := deadlines.err(); timeoutErr != nil {
+			err = timeoutErr
+		}
 		proxyErr := ClassifyError(0, err, prov.Name)
 		LogError(proxyErr)
-		h.errorTracker.RecordError(prov.Name, choice.ActualModel, 0)
+		if proxyErr.Type != ErrorTypeInvalidRequest {
+			h.errorTracker.RecordError(prov.Name, choice.ActualModel, 0)
+		}
 
 		// Log failed response
 		if h.requestLogger != nil {
@@ -40,9 +56,10 @@ func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider
 			h.requestLogger.LogResponse(prov.Name, modelName, 0, nil, nil, duration, 0, attemptNumber, false, proxyErr.Message, true)
 		}
 
-		return false
+		return false, proxyErr
 	}
 	defer resp.Body.Close()
+	h.recordRateLimit(prov.Name, choice.ActualModel, resp)
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -57,7 +74,7 @@ func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider
 			h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, respHeaders, nil, duration, 0, attemptNumber, false, proxyErr.Message, true)
 		}
 
-		return false
+		return false, proxyErr
 	}
 
 	// Success! Stream the response
@@ -66,33 +83,78 @@ func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider
 		"model", choice.ActualModel,
 		"providerType", prov.Type)
 
-	// Set streaming headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("X-Accel-Buffering", "no")
-
-	// Stream the response
-	totalTokens := 0
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
 		logger.Error("Streaming not supported")
-		return false
+		return false, ClassifyError(0, errors.New("streaming not supported"), prov.Name)
 	}
 
 	// Buffer to accumulate stream data for logging
 	var streamBuffer bytes.Buffer
 
-	// Check if we need to convert OpenAI stream to Anthropic format
-	if prov.Type == transform.ProviderTypeOpenAI {
-		// Handle OpenAI streaming with conversion
-		totalTokens = h.handleOpenAIStream(c, resp, &streamBuffer, choice.ActualModel, flusher)
-	} else {
-		// Handle native Anthropic streaming
-		totalTokens = h.handleAnthropicStream(c, resp, &streamBuffer, flusher)
+	client := &streamClient{c: c, flusher: flusher, lane: lane, choice: choice, attemptNumber: attemptNumber}
+	if format.openAI {
+		client.openAI = transform.NewAnthropicToOpenAIStreamConverter(responseModel(c, choice, modelName), format.includeUsage)
 	}
 
+	// The provider's adapter decodes its stream into Anthropic SSE
+	stream := prov.Client.DecodeStream(resp.Body, choice.ActualModel)
+	defer stream.Close()
+	outcome := h.relayStream(stream, &streamBuffer, client, deadlines)
+	usage := outcome.usage
+
 	duration := time.Since(startTime)
+	totalTokens := usage.Output()
+
+	if !outcome.firstTokenAt.IsZero() {
+		h.tracker.RecordFirstToken(prov.Name, choice.ActualModel, outcome.firstTokenAt.Sub(startTime))
+	}
+
+	if outcome.err != nil {
+		// A hedged rival is serving the client
+		if lane.lost() {
+			logger.Debug("Hedged attempt lost the race", "provider", prov.Name, "model", choice.ActualModel)
+			return false, nil
+		}
+
+		// The client went away; there is nobody left to fail over for, but the tokens
+		// the provider produced until then are still charged
+		if errors.Is(outcome.err, errClientGone) || c.Request.Context().Err() != nil {
+			logger.Debug("Client disconnected during stream", "provider", prov.Name)
+			prov.RecordTokens(choice.Model, totalTokens)
+			h.recordStreamAnalytics(c, modelName, prov.Name, usage, duration, "cancelled", errClientGone.Error())
+			if h.requestLogger != nil {
+				respHeaders := extractHeaders(resp.Header)
+				h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, respHeaders, streamBuffer.Bytes(), duration, totalTokens, attemptNumber, false, errClientGone.Error(), true)
+			}
+			return true, nil
+		}
+
+		proxyErr := StreamError(outcome.err, prov.Name)
+		LogError(proxyErr)
+		h.errorTracker.RecordError(prov.Name, choice.ActualModel, 0)
+
+		// Log failed streaming response
+		if h.requestLogger != nil {
+			respHeaders := extractHeaders(resp.Header)
+			h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, respHeaders, streamBuffer.Bytes(), duration, totalTokens, attemptNumber, false, proxyErr.Message, true)
+		}
+
+		// Nothing reached the client yet, so the next provider can take over transparently
+		if !outcome.committed {
+			return false, proxyErr
+		}
+
+		// Too late to fail over; end the stream with an error the client can see
+		if !outcome.errorSent {
+			client.writeError("api_error", proxyErr.Message)
+		}
+		h.recordStreamAnalytics(c, modelName, prov.Name, usage, duration, "error", proxyErr.Message)
+		return true, nil
+	}
+
+	// Charge the output against tokens per minute limits
+	prov.RecordTokens(choice.Model, totalTokens)
 
 	// Record metrics
 	if totalTokens > 0 {
@@ -111,19 +173,7 @@ func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider
 	h.errorTracker.RecordSuccess(prov.Name, choice.ActualModel)
 
 	// Record analytics if user tracking is enabled
-	// For streaming, we don't track detailed input/output tokens separately
-	if h.analyticsService != nil {
-		userID, hasUser := auth.GetUserID(c)
-		if hasUser {
-			tokenID, _ := auth.GetTokenID(c)
-			var tokenIDPtr *uint
-			if tokenID > 0 {
-				tokenIDPtr = &tokenID
-			}
-			// For streaming, we only have total tokens, not separated input/output
-			h.analyticsService.RecordRequest(userID, tokenIDPtr, modelName, prov.Name, 0, totalTokens, duration, "success", "")
-		}
-	}
+	h.recordStreamAnalytics(c, modelName, prov.Name, usage, duration, "success", "")
 
 	// Log successful streaming response
 	if h.requestLogger != nil {
@@ -131,159 +181,377 @@ func (h *Handler) handleStreamingRequest(c *gin.Context, prov *provider.Provider
 		h.requestLogger.LogResponse(prov.Name, modelName, resp.StatusCode, respHeaders, streamBuffer.Bytes(), duration, totalTokens, attemptNumber, true, "", true)
 	}
 
-	return true
+	return true, nil
 }
 
-// handleAnthropicStream handles native Anthropic SSE streaming
-func (h *Handler) handleAnthropicStream(c *gin.Context, resp *http.Response, streamBuffer *bytes.Buffer, flusher http.Flusher) int {
-	totalTokens := 0
-	reader := bufio.NewReader(resp.Body)
-
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err != io.EOF {
-				logger.Error("Error reading Anthropic stream", "error", err.Error())
-			}
-			break
-		}
+// recordStreamAnalytics records a streamed request for the authenticated user, if any
+func (h *Handler) recordStreamAnalytics(c *gin.Context, modelName, providerName string, usage streamUsage, duration time.Duration, status, errorMsg string) {
+	if h.analyticsService == nil {
+		return
+	}
+	userID, hasUser := auth.GetUserID(c)
+	if !hasUser {
+		return
+	}
+	tokenID, _ := auth.GetTokenID(c)
+	var tokenIDPtr *uint
+	if tokenID > 0 {
+		tokenIDPtr = &tokenID
+	}
+	h.analyticsService.RecordRequest(userID, tokenIDPtr, modelName, providerName, usage.TokenUsage(), duration, status, errorMsg, routingRule(c))
+}
 
-		// Accumulate for logging
-		if h.requestLogger != nil {
-			streamBuffer.Write(line)
-		}
+// StreamCommitPolicy decides when a streamed response is committed to the client.
+// Until then events are held back, so a provider that fails early can be replaced
+// by the next one without the client noticing.
+type StreamCommitPolicy struct {
+	OnResponse bool          // Commit as soon as the provider answers, disabling mid-stream failover
+	After      time.Duration // Commit after this long even without content; 0 waits for content
+}
 
-		// Write line to client
-		if _, writeErr := c.Writer.Write(line); writeErr != nil {
-			logger.Error("Error writing to client", "error", writeErr.Error())
-			break
-		}
+// streamOutcome describes how a relayed stream ended
+type streamOutcome struct {
+	usage        streamUsage
+	committed    bool      // Whether anything was written to the client
+	errorSent    bool      // Whether the client already received an error event
+	firstTokenAt time.Time // When the first content delta arrived; zero if none did
+	err          error     // Why the stream failed; nil if it completed
+}
 
-		// Parse SSE data to count tokens
-		if bytes.HasPrefix(line, []byte("data: ")) {
-			dataStr := string(bytes.TrimPrefix(line, []byte("data: ")))
-			dataStr = strings.TrimSpace(dataStr)
+// errClientGone ends a stream whose client can no longer be written to
+var errClientGone = errors.New("client disconnected")
 
-			// Skip [DONE] marker
-			if dataStr == "[DONE]" {
-				continue
-			}
+// streamLine is one line read from the upstream stream
+type streamLine struct {
+	line []byte
+	err  error
+}
 
-			// Parse JSON to extract tokens
-			var eventData map[string]interface{}
-			if err := json.Unmarshal([]byte(dataStr), &eventData); err == nil {
-				tokens := extractStreamTokens(eventData)
-				totalTokens += tokens
+// relayStream relays an Anthropic SSE stream to the client. Lines are buffered until
+// the commit point (the first content delta, the end of the message or the commit
+// timeout); a failure before that point leaves the client untouched. The stream
+// fails when one of the provider timeouts in deadlines fires.
+func (h *Handler) relayStream(stream io.Reader, streamBuffer *bytes.Buffer, client *streamClient, deadlines *requestDeadlines) streamOutcome {
+	var outcome streamOutcome
+	var buffered [][]byte
+	stopped := false // message_delta or message_stop seen
+
+	commit := func() bool {
+		if !client.start() {
+			outcome.err = errHedgeLost
+			return false
+		}
+		outcome.committed = true
+		for _, line := range buffered {
+			if err := client.writeLine(line); err != nil {
+				logger.Error("Error writing to client", "error", err.Error())
+				outcome.err = errClientGone
+				return false
 			}
 		}
+		buffered = nil
+		client.flusher.Flush()
+		return true
+	}
 
-		flusher.Flush()
+	if h.streamCommit.OnResponse && !commit() {
+		return outcome
 	}
 
-	return totalTokens
-}
+	var commitTimer <-chan time.Time
+	if !outcome.committed && h.streamCommit.After > 0 {
+		timer := time.NewTimer(h.streamCommit.After)
+		defer timer.Stop()
+		commitTimer = timer.C
+	}
 
-// handleOpenAIStream handles OpenAI SSE streaming and converts to Anthropic format
-func (h *Handler) handleOpenAIStream(c *gin.Context, resp *http.Response, streamBuffer *bytes.Buffer, model string, flusher http.Flusher) int {
-	totalTokens := 0
-	reader := bufio.NewReader(resp.Body)
+	// Read in the background so the commit timer can fire while the upstream is silent
+	done := make(chan struct{})
+	defer close(done)
+	lines := readStreamLines(stream, done)
+	deadlines.activity()
 
 	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err != io.EOF {
-				logger.Error("Error reading OpenAI stream", "error", err.Error())
+		var next streamLine
+		select {
+		case next = <-lines:
+		case <-deadlines.ctx.Done():
+			if err := deadlines.err(); err != nil {
+				return failedOutcome(outcome, err)
 			}
-			break
+			return failedOutcome(outcome, deadlines.ctx.Err())
+		case <-commitTimer:
+			if !commit() {
+				return outcome
+			}
+			continue
 		}
 
-		// Accumulate for logging
-		if h.requestLogger != nil {
-			streamBuffer.Write(line)
-		}
+		if len(next.line) > 0 {
+			line := next.line
+			deadlines.activity()
 
-		// Parse SSE data
-		if bytes.HasPrefix(line, []byte("data: ")) {
-			dataStr := string(bytes.TrimPrefix(line, []byte("data: ")))
-			dataStr = strings.TrimSpace(dataStr)
-
-			// Check for [DONE] marker
-			if dataStr == "[DONE]" {
-				// Write [DONE] marker in Anthropic format
-				c.Writer.Write([]byte("data: [DONE]\n\n"))
-				flusher.Flush()
-				continue
+			// Accumulate for logging
+			if h.requestLogger != nil {
+				streamBuffer.Write(line)
 			}
 
-			// Parse OpenAI chunk
-			var openaiChunk transform.OpenAIStreamChunk
-			if err := json.Unmarshal([]byte(dataStr), &openaiChunk); err != nil {
-				logger.Error("Failed to parse OpenAI stream chunk", "error", err.Error())
-				continue
+			// Parse SSE data to count tokens and find the commit point
+			eventType := ""
+			if data, ok := sseData(line); ok && data != "[DONE]" {
+				var eventData map[string]interface{}
+				if err := json.Unmarshal([]byte(data), &eventData); err == nil {
+					outcome.usage.observe(eventData)
+					eventType, _ = eventData["type"].(string)
+
+					// An error before the commit point fails over instead of reaching the client
+					if eventType == "error" {
+						if !outcome.committed {
+							return failedOutcome(outcome, upstreamStreamError(eventData))
+						}
+						outcome.errorSent = true
+						outcome.err = upstreamStreamError(eventData)
+					}
+				}
 			}
 
-			// Convert to Anthropic events
-			anthropicEvents, err := transform.OpenAIStreamToAnthropicStream([]byte(dataStr), model)
-			if err != nil {
-				logger.Error("Failed to convert OpenAI stream to Anthropic format", "error", err.Error())
-				continue
+			switch eventType {
+			case "content_block_delta":
+				deadlines.contentReceived()
+				if outcome.firstTokenAt.IsZero() {
+					outcome.firstTokenAt = time.Now()
+				}
+			case "message_delta", "message_stop":
+				stopped = true
 			}
 
-			// Write each converted event
-			for _, event := range anthropicEvents {
-				// Write SSE format: "event: message_start\ndata: {...}\n\n"
-				var eventType string
-				var eventData map[string]interface{}
-				json.Unmarshal([]byte(event), &eventData)
-				if t, ok := eventData["type"].(string); ok {
-					eventType = t
+			if outcome.committed {
+				if err := client.writeLine(line); err != nil {
+					logger.Error("Error writing to client", "error", err.Error())
+					return failedOutcome(outcome, errClientGone)
+				}
+				client.flusher.Flush()
+			} else {
+				buffered = append(buffered, line)
+				if eventType == "content_block_delta" || eventType == "message_stop" {
+					if !commit() {
+						return outcome
+					}
 				}
+			}
+		}
 
-				// Write event type if present
-				if eventType != "" {
-					c.Writer.Write([]byte("event: " + eventType + "\n"))
+		if next.err != nil {
+			if next.err != io.EOF {
+				if err := deadlines.err(); err != nil {
+					return failedOutcome(outcome, err)
 				}
+				logger.Error("Error reading provider stream", "error", next.err.Error())
+				return failedOutcome(outcome, next.err)
+			}
+			if outcome.err == nil && !stopped {
+				return failedOutcome(outcome, errors.New("provider stream ended before the message was complete"))
+			}
+			// A complete stream that never produced content is still a valid response
+			if !outcome.committed && outcome.err == nil {
+				commit()
+			}
+			return outcome
+		}
+	}
+}
 
-				// Write data
-				c.Writer.Write([]byte("data: " + event + "\n\n"))
-				flusher.Flush()
+// failedOutcome marks an outcome as failed unless it already carries an error
+func failedOutcome(outcome streamOutcome, err error) streamOutcome {
+	if outcome.err == nil {
+		outcome.err = err
+	}
+	return outcome
+}
 
-				// Count tokens
-				tokens := extractStreamTokens(eventData)
-				totalTokens += tokens
+// upstreamStreamError describes an Anthropic error event received from the provider
+func upstreamStreamError(eventData map[string]interface{}) error {
+	errorData, _ := eventData["error"].(map[string]interface{})
+	errorType, _ := errorData["type"].(string)
+	message, _ := errorData["message"].(string)
+	return fmt.Errorf("provider sent %s: %s", errorType, message)
+}
+
+// readStreamLines reads lines from the stream until it fails or done is closed
+func readStreamLines(stream io.Reader, done <-chan struct{}) <-chan streamLine {
+	lines := make(chan streamLine)
+	go func() {
+		reader := bufio.NewReader(stream)
+		for {
+			line, err := reader.ReadBytes('\n')
+			select {
+			case lines <- streamLine{line: line, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
 			}
-		} else {
-			// Forward non-data lines as-is (comments, blank lines)
-			c.Writer.Write(line)
-			flusher.Flush()
 		}
+	}()
+	return lines
+}
+
+// sseData returns the payload of an SSE data line
+func sseData(line []byte) (string, bool) {
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return "", false
 	}
+	return strings.TrimSpace(string(bytes.TrimPrefix(line, []byte("data:")))), true
+}
+
+// streamClient writes a stream back to the client, translating Anthropic events
+// into OpenAI chat completion chunks for /v1/chat/completions clients
+type streamClient struct {
+	c       *gin.Context
+	flusher http.Flusher
+	openAI  *transform.AnthropicToOpenAIStreamConverter // nil for Anthropic clients
+	lane    *hedgeLane                                  // nil unless the request is hedged
 
-	return totalTokens
+	choice        *router.ProviderChoice // Choice serving the stream, reported in the routing headers
+	attemptNumber int
 }
 
-// extractStreamTokens extracts token count from streaming event data
-func extractStreamTokens(eventData map[string]interface{}) int {
-	// Check for content_block_delta with text
-	if eventType, ok := eventData["type"].(string); ok {
-		if eventType == "content_block_delta" {
-			if delta, ok := eventData["delta"].(map[string]interface{}); ok {
-				if text, ok := delta["text"].(string); ok {
-					// Rough estimation: ~4 chars per token
-					return len(text) / 4
-				}
+// start claims the client and sets the streaming response headers; called once the
+// stream is committed. It fails if a hedged attempt already claimed the client.
+func (s *streamClient) start() bool {
+	if !s.lane.commit() {
+		return false
+	}
+	if s.lane != nil {
+		// The winning attempt switches from its copy of the request context to the real one
+		s.c = s.lane.race.client
+		s.flusher = s.c.Writer
+	}
+	s.c.Header("Content-Type", "text/event-stream")
+	s.c.Header("Cache-Control", "no-cache")
+	s.c.Header("Connection", "keep-alive")
+	s.c.Header("X-Accel-Buffering", "no")
+	setRoutingHeaders(s.c, s.choice, s.attemptNumber)
+	return true
+}
+
+// writeLine forwards one Anthropic SSE line; OpenAI clients get converted chunks for data lines instead
+func (s *streamClient) writeLine(line []byte) error {
+	if s.openAI == nil {
+		_, err := s.c.Writer.Write(line)
+		return err
+	}
+	if data, ok := sseData(line); ok && data != "[DONE]" {
+		return s.writeOpenAIChunks(data)
+	}
+	return nil
+}
+
+// writeError ends a committed stream with an Anthropic error event
+func (s *streamClient) writeError(errorType, message string) {
+	event, _ := json.Marshal(map[string]interface{}{
+		"type": "error",
+		"error": map[string]interface{}{
+			"type":    errorType,
+			"message": message,
+		},
+	})
+	if s.openAI != nil {
+		s.writeOpenAIChunks(string(event))
+		return
+	}
+	s.c.Writer.Write([]byte("event: error\ndata: " + string(event) + "\n\n"))
+	s.flusher.Flush()
+}
+
+// writeOpenAIChunks converts a single Anthropic event and writes the resulting OpenAI
+// chunks, returning the first write error
+func (s *streamClient) writeOpenAIChunks(event string) error {
+	chunks, err := s.openAI.Convert([]byte(event))
+	if err != nil {
+		logger.Error("Failed to convert stream to OpenAI format", "error", err.Error())
+		return nil
+	}
+
+	for _, chunk := range chunks {
+		if _, err := s.c.Writer.Write([]byte("data: " + chunk + "\n\n")); err != nil {
+			return err
+		}
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// streamUsage accumulates token usage from Anthropic stream events. Prompt and
+// cache tokens arrive in message_start, output tokens in the final message_delta;
+// the per-delta estimate is only used when the provider reports no output tokens.
+type streamUsage struct {
+	InputTokens              int
+	OutputTokens             int
+	CacheCreationInputTokens int
+	CacheReadInputTokens     int
+	EstimatedOutput          int // ~4 chars per token of streamed deltas
+}
+
+// observe records usage from a single Anthropic stream event
+func (u *streamUsage) observe(eventData map[string]interface{}) {
+	eventType, _ := eventData["type"].(string)
+
+	switch eventType {
+	case "message_start":
+		if message, ok := eventData["message"].(map[string]interface{}); ok {
+			if usage, ok := message["usage"].(map[string]interface{}); ok {
+				u.merge(usage)
 			}
 		}
 
-		// Check for message_delta with usage
-		if eventType == "message_delta" {
-			if usage, ok := eventData["usage"].(map[string]interface{}); ok {
-				if outputTokens, ok := usage["output_tokens"].(float64); ok {
-					return int(outputTokens)
+	case "content_block_delta":
+		if delta, ok := eventData["delta"].(map[string]interface{}); ok {
+			for _, key := range []string{"text", "thinking", "partial_json"} {
+				if text, ok := delta[key].(string); ok {
+					u.EstimatedOutput += len(text) / 4
 				}
 			}
 		}
+
+	case "message_delta":
+		// message_delta usage is cumulative, so it replaces rather than adds
+		if usage, ok := eventData["usage"].(map[string]interface{}); ok {
+			u.merge(usage)
+		}
+	}
+}
+
+// merge overwrites counts with the non-zero values of an Anthropic usage object
+func (u *streamUsage) merge(usage map[string]interface{}) {
+	fields := map[string]*int{
+		"input_tokens":                &u.InputTokens,
+		"output_tokens":               &u.OutputTokens,
+		"cache_creation_input_tokens": &u.CacheCreationInputTokens,
+		"cache_read_input_tokens":     &u.CacheReadInputTokens,
+	}
+	for key, field := range fields {
+		if value, ok := usage[key].(float64); ok && value > 0 {
+			*field = int(value)
+		}
 	}
+}
 
-	return 0
+// Output returns the reported output tokens, falling back to the estimate
+func (u *streamUsage) Output() int {
+	if u.OutputTokens > 0 {
+		return u.OutputTokens
+	}
+	return u.EstimatedOutput
+}
+
+// TokenUsage converts the accumulated counts for analytics
+func (u *streamUsage) TokenUsage() analytics.TokenUsage {
+	return analytics.TokenUsage{
+		InputTokens:              u.InputTokens,
+		OutputTokens:             u.Output(),
+		CacheCreationInputTokens: u.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens,
+	}
 }
@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"anthropic-proxy/analytics"
+	"anthropic-proxy/transform"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingWriter is a response writer whose client has gone away
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+var errBrokenPipe = errors.New("broken pipe")
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errBrokenPipe
+}
+
+func TestStreamClientWriteLineReportsWriteErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		openAI bool
+	}{
+		{name: "anthropic client"},
+		{name: "openai client", openAI: true},
+	}
+
+	line := []byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}` + "\n")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := failingWriter{httptest.NewRecorder()}
+			c, _ := gin.CreateTestContext(writer)
+			client := &streamClient{c: c, flusher: writer}
+			if tt.openAI {
+				client.openAI = transform.NewAnthropicToOpenAIStreamConverter("model", false)
+				// The converter only emits content chunks once the message has started
+				if err := client.writeLine([]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{}}}` + "\n")); err != nil && !errors.Is(err, errBrokenPipe) {
+					t.Fatalf("message_start: %v", err)
+				}
+			}
+
+			if err := client.writeLine(line); !errors.Is(err, errBrokenPipe) {
+				t.Errorf("writeLine error = %v, want %v", err, errBrokenPipe)
+			}
+		})
+	}
+}
+
+func TestStreamUsageObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   analytics.TokenUsage
+	}{
+		{
+			name: "reported usage",
+			events: []string{
+				`{"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1,"cache_creation_input_tokens":100,"cache_read_input_tokens":300}}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello there"}}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`,
+			},
+			want: analytics.TokenUsage{InputTokens: 12, OutputTokens: 7, CacheCreationInputTokens: 100, CacheReadInputTokens: 300},
+		},
+		{
+			name: "cumulative message_delta replaces earlier counts",
+			events: []string{
+				`{"type":"message_start","message":{"usage":{"input_tokens":12}}}`,
+				`{"type":"message_delta","usage":{"output_tokens":3}}`,
+				`{"type":"message_delta","usage":{"input_tokens":15,"output_tokens":9}}`,
+			},
+			want: analytics.TokenUsage{InputTokens: 15, OutputTokens: 9},
+		},
+		{
+			name: "output estimated from deltas",
+			events: []string{
+				`{"type":"message_start","message":{"usage":{"input_tokens":12}}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"12345678"}}`,
+				`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"1234"}}`,
+				`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"a\":1234}"}}`,
+				`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":0}}`,
+			},
+			want: analytics.TokenUsage{InputTokens: 12, OutputTokens: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usage streamUsage
+			for _, event := range tt.events {
+				var eventData map[string]interface{}
+				if err := json.Unmarshal([]byte(event), &eventData); err != nil {
+					t.Fatalf("invalid event %s: %v", event, err)
+				}
+				usage.observe(eventData)
+			}
+			if got := usage.TokenUsage(); got != tt.want {
+				t.Errorf("TokenUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
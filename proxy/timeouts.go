@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"anthropic-proxy/provider"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errProviderTimeout is wrapped by every provider timeout so they can be told apart from other cancellations
+var errProviderTimeout = errors.New("provider timeout")
+
+// timeoutError describes a provider timeout that fired
+func timeoutError(name string, timeout time.Duration) error {
+	return fmt.Errorf("%w: %s of %s exceeded", errProviderTimeout, name, timeout)
+}
+
+// requestDeadlines enforces a provider's first-token, idle-stream and total timeouts.
+// A timeout cancels the request context with a cause naming it, so it surfaces through
+// the normal failure path: before the stream is committed the next provider takes over,
+// after that the client receives an error event.
+type requestDeadlines struct {
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	firstToken  *time.Timer
+	idle        *time.Timer
+	idleTimeout time.Duration
+	total       *time.Timer
+}
+
+// newRequestDeadlines starts the first-token and total timers for one attempt; streaming
+// selects whether the stream timeouts apply
+func newRequestDeadlines(parent context.Context, timeouts provider.Timeouts, streaming bool) *requestDeadlines {
+	ctx, cancel := context.WithCancelCause(parent)
+	d := &requestDeadlines{ctx: ctx, cancel: cancel}
+
+	if timeouts.Total > 0 {
+		d.total = time.AfterFunc(timeouts.Total, d.expire("total timeout", timeouts.Total))
+	}
+	if streaming {
+		if timeouts.FirstToken > 0 {
+			d.firstToken = time.AfterFunc(timeouts.FirstToken, d.expire("first token timeout", timeouts.FirstToken))
+		}
+		d.idleTimeout = timeouts.IdleStream
+	}
+	return d
+}
+
+// expire returns a timer callback that cancels the request with the named timeout
+func (d *requestDeadlines) expire(name string, timeout time.Duration) func() {
+	return func() {
+		d.cancel(timeoutError(name, timeout))
+	}
+}
+
+// contentReceived stops the first-token timer
+func (d *requestDeadlines) contentReceived() {
+	if d.firstToken != nil {
+		d.firstToken.Stop()
+	}
+}
+
+// activity restarts the idle-stream timer; the first call starts it
+func (d *requestDeadlines) activity() {
+	if d.idleTimeout <= 0 {
+		return
+	}
+	if d.idle == nil {
+		d.idle = time.AfterFunc(d.idleTimeout, d.expire("idle stream timeout", d.idleTimeout))
+		return
+	}
+	d.idle.Reset(d.idleTimeout)
+}
+
+// err returns the timeout that cancelled the request, or nil
+func (d *requestDeadlines) err() error {
+	if cause := context.Cause(d.ctx); errors.Is(cause, errProviderTimeout) {
+		return cause
+	}
+	return nil
+}
+
+// stop releases the timers and the request context
+func (d *requestDeadlines) stop() {
+	for _, timer := range []*time.Timer{d.firstToken, d.idle, d.total} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	d.cancel(nil)
+}
@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	_ "image/gif" // Registered for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	imageTokens           = 1600      // Images whose size cannot be read: the cost of a full-size image
+	imagePixelsPerToken   = 750       // Anthropic's rule of thumb for resized images
+	imageMaxEdge          = 1568      // Longer edges are scaled down to this before counting
+	imageMaxPixels        = 1_150_000 // Larger images are scaled down to about this many pixels
+	imageHeaderBytes      = 64 << 10  // Decoded prefix of a base64 image searched for its dimensions
+	pdfPageTokens         = 3000      // Text and page image of a typical PDF page
+	messageOverheadTokens = 4         // Role and separators around each message
+	toolsOverheadTokens   = 346       // System prompt the provider adds when tools are defined
+	toolOverheadTokens    = 8         // Separators around each tool definition
+)
+
+// pdfPagePattern matches the page objects of a PDF, but not the page tree ("/Type /Pages")
+var pdfPagePattern = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// estimatePromptTokens sizes the prompt of an Anthropic messages request locally: system,
+// messages, tool definitions, images, documents and thinking. Text goes through a
+// heuristic tokenizer close to Claude's; images are sized from their dimensions and
+// PDFs from their page count. It answers count_tokens when no provider can, and sizes
+// requests for context window routing and tokens per minute limits.
+func estimatePromptTokens(requestBody map[string]interface{}) int {
+	tokens := countContentTokens(requestBody["system"])
+
+	if messages, ok := requestBody["messages"].([]interface{}); ok {
+		for _, message := range messages {
+			tokens += messageOverheadTokens
+			if msg, ok := message.(map[string]interface{}); ok {
+				tokens += countContentTokens(msg["content"])
+			}
+		}
+	}
+
+	if tools, ok := requestBody["tools"].([]interface{}); ok && len(tools) > 0 {
+		tokens += toolsOverheadTokens
+		for _, tool := range tools {
+			tokens += toolOverheadTokens + countJSONTokens(tool)
+		}
+	}
+
+	return tokens
+}
+
+// countContentTokens counts a content value: a string or a list of blocks
+func countContentTokens(content interface{}) int {
+	switch value := content.(type) {
+	case string:
+		return countTextTokens(value)
+
+	case []interface{}:
+		tokens := 0
+		for _, item := range value {
+			tokens += countContentTokens(item)
+		}
+		return tokens
+
+	case map[string]interface{}:
+		switch blockType, _ := value["type"].(string); blockType {
+		case "image":
+			return countImageTokens(value["source"])
+		case "redacted_thinking":
+			// Encrypted thinking has no countable text
+			return 0
+		}
+		tokens := 0
+		for key, field := range value {
+			switch key {
+			case "type", "id", "tool_use_id", "signature", "cache_control", "citations", "encrypted_content":
+				// Identifiers, opaque payloads and settings are not prompt text
+			case "source":
+				tokens += countSourceTokens(field)
+			case "input":
+				tokens += countJSONTokens(field)
+			default:
+				tokens += countContentTokens(field)
+			}
+		}
+		return tokens
+	}
+	return 0
+}
+
+// countSourceTokens counts a document source: plain text counts as text, PDFs by their pages
+func countSourceTokens(source interface{}) int {
+	src, ok := source.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	switch sourceType, _ := src["type"].(string); sourceType {
+	case "text":
+		data, _ := src["data"].(string)
+		return countTextTokens(data)
+	case "content":
+		return countContentTokens(src["content"])
+	case "base64":
+		data, _ := src["data"].(string)
+		if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+			if pages := len(pdfPagePattern.FindAllIndex(decoded, -1)); pages > 0 {
+				return pages * pdfPageTokens
+			}
+		}
+		return pdfPageTokens
+	default:
+		// URLs and uploaded files cannot be inspected
+		return pdfPageTokens
+	}
+}
+
+// countImageTokens sizes an image from its dimensions the way Anthropic does: scaled down
+// to fit the maximum edge and pixel count, then one token per 750 pixels. Images given by
+// URL or in an unknown format count as a full-size image.
+func countImageTokens(source interface{}) int {
+	src, ok := source.(map[string]interface{})
+	if !ok {
+		return imageTokens
+	}
+	data, _ := src["data"].(string)
+	if data == "" {
+		return imageTokens
+	}
+
+	// The dimensions are in the header; decode only the start of the payload
+	if len(data) > imageHeaderBytes/3*4 {
+		data = data[:imageHeaderBytes/3*4]
+	}
+	header, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return imageTokens
+	}
+	dims, _, err := image.DecodeConfig(bytes.NewReader(header))
+	if err != nil || dims.Width <= 0 || dims.Height <= 0 {
+		return imageTokens
+	}
+
+	width, height := float64(dims.Width), float64(dims.Height)
+	if longEdge := max(width, height); longEdge > imageMaxEdge {
+		width, height = width*imageMaxEdge/longEdge, height*imageMaxEdge/longEdge
+	}
+	pixels := min(width*height, imageMaxPixels)
+	return max(int(pixels/imagePixelsPerToken), 1)
+}
+
+// countJSONTokens counts a value as its JSON encoding, as tool definitions and tool inputs are sent
+func countJSONTokens(value interface{}) int {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return countTextTokens(string(encoded))
+}
+
+// countTextTokens approximates how Claude's tokenizer splits text. Words of up to six
+// letters take one token and longer words one more per four letters; letters outside
+// the Latin script take one token per two. Digits go three to a token, each CJK
+// character and punctuation mark is a token, and a single space joins the following
+// word while longer whitespace runs and line breaks take a token of their own.
+func countTextTokens(text string) int {
+	tokens := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+
+		switch {
+		case isCJK(r):
+			tokens++
+			text = text[size:]
+
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			end := strings.IndexFunc(text, func(r rune) bool {
+				return !(unicode.IsLetter(r) || unicode.IsMark(r)) || isCJK(r)
+			})
+			if end < 0 {
+				end = len(text)
+			}
+			tokens += wordTokens(text[:end])
+			text = text[end:]
+
+		case unicode.IsDigit(r):
+			end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsDigit(r) })
+			if end < 0 {
+				end = len(text)
+			}
+			tokens += (utf8.RuneCountInString(text[:end]) + 2) / 3
+			text = text[end:]
+
+		case unicode.IsSpace(r):
+			end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) })
+			if end < 0 {
+				end = len(text)
+			}
+			if end > 1 || r == '\n' {
+				tokens++
+			}
+			text = text[end:]
+
+		default:
+			tokens++
+			text = text[size:]
+		}
+	}
+	return tokens
+}
+
+// wordTokens counts the tokens of a run of letters
+func wordTokens(word string) int {
+	letters := utf8.RuneCountInString(word)
+	nonLatin := strings.IndexFunc(word, func(r rune) bool {
+		return !unicode.Is(unicode.Latin, r) && !unicode.IsMark(r)
+	})
+	if nonLatin >= 0 {
+		// Other scripts are split into shorter pieces
+		return (letters + 1) / 2
+	}
+	if letters <= 6 {
+		return 1
+	}
+	return 1 + (letters-6+3)/4
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestCountTextTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "hello", want: 1},
+		{text: "hello world", want: 2},
+		{text: "internationalization", want: 5},
+		{text: "12345", want: 2},
+		{text: "a\nb", want: 3},
+		{text: "a   b", want: 3},
+		{text: "Hi!", want: 2},
+		{text: "你好", want: 2},
+		{text: "привет", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := countTextTokens(tt.text); got != tt.want {
+			t.Errorf("countTextTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// pngBase64 encodes a blank PNG image of the given size
+func pngBase64(t *testing.T, width, height int) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestCountContentTokensMedia(t *testing.T) {
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.7 /Type /Pages /Type /Page /Type /Page"))
+
+	tests := []struct {
+		name  string
+		block map[string]interface{}
+		want  int
+	}{
+		{
+			name:  "small image",
+			block: map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "data": pngBase64(t, 200, 100)}},
+			want:  26,
+		},
+		{
+			name:  "large image is scaled down",
+			block: map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "data": pngBase64(t, 3000, 1500)}},
+			want:  1533,
+		},
+		{
+			name:  "url image",
+			block: map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "url", "url": "https://example.com/a.png"}},
+			want:  imageTokens,
+		},
+		{
+			name:  "pdf pages",
+			block: map[string]interface{}{"type": "document", "source": map[string]interface{}{"type": "base64", "data": pdf}},
+			want:  2 * pdfPageTokens,
+		},
+		{
+			name:  "text document",
+			block: map[string]interface{}{"type": "document", "source": map[string]interface{}{"type": "text", "data": "hello world"}},
+			want:  2,
+		},
+		{
+			name:  "redacted thinking",
+			block: map[string]interface{}{"type": "redacted_thinking", "data": "c2VjcmV0"},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countContentTokens(tt.block); got != tt.want {
+				t.Errorf("countContentTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
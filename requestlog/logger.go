@@ -25,6 +25,7 @@ type LogEntry struct {
 	Success       bool              `json:"success"`
 	Error         string            `json:"error,omitempty"`
 	IsStreaming   bool              `json:"is_streaming,omitempty"`
+	Rule          string            `json:"rule,omitempty"` // Routing rule that matched the request
 }
 
 // RequestLogger handles logging of HTTP requests and responses to a file
@@ -47,7 +48,7 @@ func NewRequestLogger(filePath string) (*RequestLogger, error) {
 }
 
 // LogRequest logs an outgoing request
-func (rl *RequestLogger) LogRequest(provider, model, method, path string, headers map[string]string, body []byte, attemptNumber int, isStreaming bool) error {
+func (rl *RequestLogger) LogRequest(provider, model, method, path string, headers map[string]string, body []byte, attemptNumber int, isStreaming bool, rule string) error {
 	// Defensive nil check to prevent panics
 	if rl == nil {
 		return nil // Silently skip logging if logger is not initialized
@@ -65,6 +66,7 @@ func (rl *RequestLogger) LogRequest(provider, model, method, path string, header
 		Body:          string(body),
 		Success:       true, // Requests are always "successful" in terms of being sent
 		IsStreaming:   isStreaming,
+		Rule:          rule,
 	}
 
 	return rl.writeEntry(entry)
@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AfterError is a retriable error carrying the delay the provider asked for
+type AfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// Error implements the error interface
+func (e *AfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *AfterError) Unwrap() error {
+	return e.Err
+}
+
+// After wraps err so Do waits for delay instead of the computed backoff
+func After(err error, delay time.Duration) error {
+	if delay <= 0 {
+		return err
+	}
+	return &AfterError{Err: err, Delay: delay}
+}
+
+// advertisedDelay returns the delay carried by an AfterError, if any
+func advertisedDelay(err error) (time.Duration, bool) {
+	var afterErr *AfterError
+	if errors.As(err, &afterErr) {
+		return afterErr.Delay, true
+	}
+	return 0, false
+}
+
+// rateLimitHeaders pairs the remaining and reset headers of one provider rate limit
+var rateLimitHeaders = []struct {
+	remaining string
+	reset     string
+}{
+	// Anthropic: resets are RFC 3339 timestamps
+	{"Anthropic-Ratelimit-Requests-Remaining", "Anthropic-Ratelimit-Requests-Reset"},
+	{"Anthropic-Ratelimit-Tokens-Remaining", "Anthropic-Ratelimit-Tokens-Reset"},
+	{"Anthropic-Ratelimit-Input-Tokens-Remaining", "Anthropic-Ratelimit-Input-Tokens-Reset"},
+	{"Anthropic-Ratelimit-Output-Tokens-Remaining", "Anthropic-Ratelimit-Output-Tokens-Reset"},
+	// OpenAI: resets are durations such as "1s" or "6m0s"
+	{"X-Ratelimit-Remaining-Requests", "X-Ratelimit-Reset-Requests"},
+	{"X-Ratelimit-Remaining-Tokens", "X-Ratelimit-Reset-Tokens"},
+}
+
+// RateLimitDelay returns how long a provider asked to be left alone, read from
+// Retry-After and the Anthropic and OpenAI rate limit headers. For rate limited or
+// overloaded responses (429, 503, 529) any advertised reset counts; for other
+// responses only the reset of an exhausted limit does. It returns 0 if nothing is advertised.
+func RateLimitDelay(statusCode int, header http.Header, now time.Time) time.Duration {
+	limited := statusCode == 429 || statusCode == 503 || statusCode == 529
+
+	if limited {
+		if delay, ok := retryAfter(header, now); ok {
+			return delay
+		}
+	}
+
+	var delay time.Duration
+	for _, limit := range rateLimitHeaders {
+		remaining := header.Get(limit.remaining)
+		exhausted := remaining == "0" || (limited && remaining == "")
+		if !exhausted {
+			continue
+		}
+		if reset, ok := parseReset(header.Get(limit.reset), now); ok && reset > delay {
+			delay = reset
+		}
+	}
+	return delay
+}
+
+// retryAfter parses Retry-After-Ms and Retry-After (seconds or an HTTP date)
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if value := header.Get("Retry-After-Ms"); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return nonNegative(at.Sub(now)), true
+	}
+	return 0, false
+}
+
+// parseReset reads a reset header given as an RFC 3339 timestamp, a duration or seconds
+func parseReset(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return nonNegative(at.Sub(now)), true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return nonNegative(d), true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return nonNegative(time.Duration(seconds * float64(time.Second))), true
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		header     map[string]string
+		want       time.Duration
+	}{
+		{
+			name:       "nothing advertised",
+			statusCode: 429,
+			want:       0,
+		},
+		{
+			name:       "retry-after seconds",
+			statusCode: 429,
+			header:     map[string]string{"Retry-After": "7"},
+			want:       7 * time.Second,
+		},
+		{
+			name:       "retry-after milliseconds win over seconds",
+			statusCode: 529,
+			header:     map[string]string{"Retry-After-Ms": "1500", "Retry-After": "7"},
+			want:       1500 * time.Millisecond,
+		},
+		{
+			name:       "retry-after http date",
+			statusCode: 503,
+			header:     map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)},
+			want:       90 * time.Second,
+		},
+		{
+			name:       "retry-after date in the past",
+			statusCode: 429,
+			header:     map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)},
+			want:       0,
+		},
+		{
+			name:       "retry-after ignored on other statuses",
+			statusCode: 500,
+			header:     map[string]string{"Retry-After": "7"},
+			want:       0,
+		},
+		{
+			name:       "anthropic reset timestamp on 429",
+			statusCode: 429,
+			header:     map[string]string{"Anthropic-Ratelimit-Tokens-Reset": now.Add(30 * time.Second).Format(time.RFC3339)},
+			want:       30 * time.Second,
+		},
+		{
+			name:       "openai reset durations, longest wins",
+			statusCode: 429,
+			header: map[string]string{
+				"X-Ratelimit-Reset-Requests": "1s",
+				"X-Ratelimit-Reset-Tokens":   "6m0s",
+			},
+			want: 6 * time.Minute,
+		},
+		{
+			name:       "exhausted limit on success",
+			statusCode: 200,
+			header: map[string]string{
+				"X-Ratelimit-Remaining-Requests": "0",
+				"X-Ratelimit-Reset-Requests":     "20s",
+				"X-Ratelimit-Remaining-Tokens":   "1000",
+				"X-Ratelimit-Reset-Tokens":       "5m",
+			},
+			want: 20 * time.Second,
+		},
+		{
+			name:       "limit with room left on success",
+			statusCode: 200,
+			header: map[string]string{
+				"Anthropic-Ratelimit-Requests-Remaining": "12",
+				"Anthropic-Ratelimit-Requests-Reset":     now.Add(time.Minute).Format(time.RFC3339),
+			},
+			want: 0,
+		},
+		{
+			name:       "reset without remaining only counts when rate limited",
+			statusCode: 500,
+			header:     map[string]string{"X-Ratelimit-Reset-Tokens": "10s"},
+			want:       0,
+		},
+		{
+			name:       "reset in seconds",
+			statusCode: 429,
+			header:     map[string]string{"X-Ratelimit-Reset-Requests": "2.5"},
+			want:       2500 * time.Millisecond,
+		},
+		{
+			name:       "unparseable values",
+			statusCode: 429,
+			header:     map[string]string{"Retry-After": "soon", "X-Ratelimit-Reset-Requests": "later"},
+			want:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+			if got := RateLimitDelay(tt.statusCode, header, now); got != tt.want {
+				t.Errorf("RateLimitDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
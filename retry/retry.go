@@ -13,6 +13,7 @@ type Config struct {
 	InitialDelay       time.Duration
 	MaxDelay           time.Duration
 	BackoffMultiplier  float64
+	MaxRetryAfter      time.Duration // Cap on delays advertised by the provider; 0 means no cap
 }
 
 // DefaultConfig returns a default retry configuration
@@ -22,13 +23,15 @@ func DefaultConfig() *Config {
 		InitialDelay:      100 * time.Millisecond,
 		MaxDelay:          5 * time.Second,
 		BackoffMultiplier: 2.0,
+		MaxRetryAfter:     30 * time.Second,
 	}
 }
 
 // Func is a function that can be retried
 type Func func() error
 
-// Do executes the given function with retry logic and exponential backoff
+// Do executes the given function with retry logic and exponential backoff.
+// An error wrapped with After waits for its advertised delay instead.
 func Do(ctx context.Context, config *Config, fn Func) error {
 	if config == nil {
 		config = DefaultConfig()
@@ -62,6 +65,14 @@ func Do(ctx context.Context, config *Config, fn Func) error {
 			delay = config.MaxDelay
 		}
 
+		// Prefer the delay the provider advertised (Retry-After or rate limit reset)
+		if advertised, ok := advertisedDelay(err); ok {
+			delay = advertised
+			if config.MaxRetryAfter > 0 && delay > config.MaxRetryAfter {
+				delay = config.MaxRetryAfter
+			}
+		}
+
 		// Sleep with context cancellation support
 		select {
 		case <-time.After(delay):
@@ -8,8 +8,8 @@ var (
 	// ErrNoModelFound is returned when no model matches the requested name
 	ErrNoModelFound = errors.New("no model found matching the requested name")
 
-	// ErrNoProvidersAvailable is returned when no providers meet the requirements
-	ErrNoProvidersAvailable = errors.New("no providers available that meet TPS threshold")
+	// ErrNoProvidersAvailable is returned when no configured provider serves the matching models
+	ErrNoProvidersAvailable = errors.New("no providers available for the requested model")
 
 	// ErrAllProvidersFailed is returned when all providers have been tried and failed
 	ErrAllProvidersFailed = errors.New("all providers failed")
@@ -27,9 +27,19 @@ func NewFallbackManager(selector *Selector) *FallbackManager {
 	}
 }
 
+// ApplyRules applies the first matching routing rule to the request
+func (f *FallbackManager) ApplyRules(req *Request) {
+	f.selector.ApplyRules(req)
+}
+
 // GetOrderedProviders returns an ordered list of providers to try
-func (f *FallbackManager) GetOrderedProviders(requestedModel string, thinkingEnabled bool) ([]*ProviderChoice, error) {
-	return f.selector.SelectProviders(requestedModel, thinkingEnabled)
+func (f *FallbackManager) GetOrderedProviders(req Request) ([]*ProviderChoice, error) {
+	return f.selector.SelectProviders(req)
+}
+
+// GetFallback returns the next alias to degrade to under the given conditions, if one is configured
+func (f *FallbackManager) GetFallback(requestedModel string, conditions []string, visited map[string]bool) (string, bool) {
+	return f.selector.Fallback(requestedModel, conditions, visited)
 }
 
 // ShouldRetry determines if we should retry with the next provider
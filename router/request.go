@@ -0,0 +1,100 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Request describes what a client request needs from the models it is routed to
+type Request struct {
+	Model       string // Requested model name or alias
+	Thinking    bool   // Extended thinking is enabled
+	InputTokens int    // Estimated prompt size; 0 skips the context window check
+	MaxTokens   int    // Requested output token limit
+
+	Tools         bool // Tool definitions are present
+	Images        bool // Image blocks are present
+	PDFs          bool // PDF document blocks are present
+	PromptCaching bool // cache_control breakpoints are present
+
+	// Who sent the request and what it says, for routing rules
+	UserID    uint        // User of a database token; 0 for static keys
+	TokenName string      // Name of a database token
+	Headers   http.Header // Client request headers
+	System    string      // System prompt text
+
+	// Routing changes made by a matching rule or the client's override headers
+	Rule             string   // Name of the rule that matched
+	Providers        []string // Only use models on these providers
+	ExcludeProviders []string // Never use models on these providers
+	Strategy         string   // Routing strategy instead of the alias's
+	NoFallback       bool     // Try only the first choice, with no failover or fallback aliases
+}
+
+// ContextTokens returns the context window the request needs
+func (r Request) ContextTokens() int {
+	return r.InputTokens + r.MaxTokens
+}
+
+// MaxOutputTokens labels a max_tokens above a model's maxOutputTokens in missing capability lists
+const MaxOutputTokens = "max-output-tokens"
+
+// Capabilities returns the model capabilities the request uses
+func (r Request) Capabilities() []string {
+	var capabilities []string
+	if r.Tools {
+		capabilities = append(capabilities, config.CapabilityTools)
+	}
+	if r.Images {
+		capabilities = append(capabilities, config.CapabilityVision)
+	}
+	if r.PDFs {
+		capabilities = append(capabilities, config.CapabilityPDF)
+	}
+	if r.Thinking {
+		capabilities = append(capabilities, config.CapabilityThinking)
+	}
+	if r.PromptCaching {
+		capabilities = append(capabilities, config.CapabilityPromptCaching)
+	}
+	return capabilities
+}
+
+// missingCapabilities returns what the request uses that a model does not support
+func (r Request) missingCapabilities(modelConfig *config.Model) []string {
+	var missing []string
+	for _, capability := range r.Capabilities() {
+		if !modelConfig.HasCapability(capability) {
+			missing = append(missing, capability)
+		}
+	}
+	if modelConfig.MaxOutputTokens > 0 && r.MaxTokens > modelConfig.MaxOutputTokens {
+		missing = append(missing, MaxOutputTokens)
+	}
+	return missing
+}
+
+// CapabilityError is returned when no matching model supports what the request uses
+// and the alias is configured to fail rather than degrade
+type CapabilityError struct {
+	Model   string
+	Missing []string // Capabilities missing from the closest model
+}
+
+// Error implements the error interface
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("no model matching %s supports: %s", e.Model, strings.Join(e.Missing, ", "))
+}
+
+// ContextWindowError is returned when no matching model has a context window large enough for the request
+type ContextWindowError struct {
+	Tokens  int // Estimated prompt plus max_tokens
+	Maximum int // Largest context window among the matching models
+}
+
+// Error implements the error interface
+func (e *ContextWindowError) Error() string {
+	return fmt.Sprintf("prompt is too long: %d tokens > %d maximum", e.Tokens, e.Maximum)
+}
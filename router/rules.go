@@ -0,0 +1,127 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/logger"
+	"anthropic-proxy/model"
+	"regexp"
+	"time"
+)
+
+// ApplyRules applies the first routing rule matching the request: it may rewrite the
+// target model, restrict providers and set the routing strategy. It runs before the
+// requested model is resolved, so a rewritten model is what gets matched.
+func (s *Selector) ApplyRules(req *Request) {
+	now := time.Now()
+	for _, rule := range s.modelRegistry.GetRules() {
+		if !s.ruleMatches(rule.Match, req, now) {
+			continue
+		}
+
+		req.Rule = rule.Name
+		if rule.Model != "" {
+			req.Model = rule.Model
+		}
+		if len(rule.Providers) > 0 {
+			req.Providers = rule.Providers
+		}
+		if rule.Strategy != "" {
+			req.Strategy = rule.Strategy
+		}
+
+		logger.Debug("Routing rule matched",
+			"rule", rule.Name,
+			"model", req.Model,
+			"providers", req.Providers,
+			"strategy", req.Strategy)
+		return
+	}
+}
+
+// ruleMatches reports whether every condition set on a rule holds for the request
+func (s *Selector) ruleMatches(match config.RuleMatch, req *Request, now time.Time) bool {
+	if len(match.Models) > 0 && !matchesAny(match.Models, req.Model) {
+		return false
+	}
+
+	if len(match.Users) > 0 {
+		found := false
+		for _, userID := range match.Users {
+			if userID == req.UserID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(match.Tokens) > 0 && (req.TokenName == "" || !matchesAny(match.Tokens, req.TokenName)) {
+		return false
+	}
+
+	for name, pattern := range match.Headers {
+		value := req.Headers.Get(name)
+		if value == "" || !model.MatchAlias(pattern, value) {
+			return false
+		}
+	}
+
+	if match.SystemPrompt != "" && !s.pattern(match.SystemPrompt).MatchString(req.System) {
+		return false
+	}
+
+	if match.Tools != nil && *match.Tools != req.Tools {
+		return false
+	}
+
+	if match.MinInputTokens > 0 && req.InputTokens < match.MinInputTokens {
+		return false
+	}
+	if match.MaxInputTokens > 0 && req.InputTokens > match.MaxInputTokens {
+		return false
+	}
+
+	if match.TimeOfDay != "" {
+		start, end, err := config.ParseTimeOfDay(match.TimeOfDay)
+		if err != nil {
+			return false
+		}
+		minute := now.Hour()*60 + now.Minute()
+		if start <= end {
+			if minute < start || minute >= end {
+				return false
+			}
+		} else if minute < start && minute >= end {
+			// The window wraps past midnight
+			return false
+		}
+	}
+
+	return true
+}
+
+// pattern returns a compiled system prompt pattern, caching it across requests
+func (s *Selector) pattern(expr string) *regexp.Regexp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if re, exists := s.patterns[expr]; exists {
+		return re
+	}
+	// Patterns are checked when the config is validated
+	re := regexp.MustCompile(expr)
+	s.patterns[expr] = re
+	return re
+}
+
+// matchesAny reports whether value matches one of the wildcard patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if model.MatchAlias(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRuleMatches(t *testing.T) {
+	yes := true
+	req := Request{
+		Model:       "claude-sonnet-4",
+		UserID:      7,
+		TokenName:   "ci-runner",
+		Headers:     http.Header{"X-Team": []string{"search"}},
+		System:      "You are a code review assistant.",
+		Tools:       true,
+		InputTokens: 5000,
+	}
+	noon := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		match config.RuleMatch
+		now   time.Time
+		want  bool
+	}{
+		{name: "no conditions", match: config.RuleMatch{}, want: true},
+		{name: "model pattern", match: config.RuleMatch{Models: []string{"gpt-*", "claude-*"}}, want: true},
+		{name: "other model", match: config.RuleMatch{Models: []string{"claude-opus*"}}, want: false},
+		{name: "user", match: config.RuleMatch{Users: []uint{3, 7}}, want: true},
+		{name: "other user", match: config.RuleMatch{Users: []uint{3}}, want: false},
+		{name: "token pattern", match: config.RuleMatch{Tokens: []string{"ci-*"}}, want: true},
+		{name: "header pattern", match: config.RuleMatch{Headers: map[string]string{"x-team": "sea*"}}, want: true},
+		{name: "header present", match: config.RuleMatch{Headers: map[string]string{"X-Team": "*"}}, want: true},
+		{name: "header missing", match: config.RuleMatch{Headers: map[string]string{"X-Other": "*"}}, want: false},
+		{name: "system prompt", match: config.RuleMatch{SystemPrompt: `(?i)code review`}, want: true},
+		{name: "other system prompt", match: config.RuleMatch{SystemPrompt: `^Translate`}, want: false},
+		{name: "tools", match: config.RuleMatch{Tools: &yes}, want: true},
+		{name: "input tokens in range", match: config.RuleMatch{MinInputTokens: 1000, MaxInputTokens: 8000}, want: true},
+		{name: "input tokens below minimum", match: config.RuleMatch{MinInputTokens: 10000}, want: false},
+		{name: "input tokens above maximum", match: config.RuleMatch{MaxInputTokens: 4000}, want: false},
+		{name: "inside time window", match: config.RuleMatch{TimeOfDay: "09:00-17:00"}, now: noon, want: true},
+		{name: "outside time window", match: config.RuleMatch{TimeOfDay: "13:00-17:00"}, now: noon, want: false},
+		{name: "window past midnight", match: config.RuleMatch{TimeOfDay: "22:00-06:00"}, now: noon.Add(11 * time.Hour), want: true},
+		{name: "outside window past midnight", match: config.RuleMatch{TimeOfDay: "22:00-06:00"}, now: noon, want: false},
+		{
+			name:  "one failing condition",
+			match: config.RuleMatch{Models: []string{"claude-*"}, Users: []uint{7}, Tokens: []string{"web-*"}},
+			want:  false,
+		},
+	}
+
+	selector := &Selector{patterns: make(map[string]*regexp.Regexp)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selector.ruleMatches(tt.match, &req, tt.now); got != tt.want {
+				t.Errorf("ruleMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
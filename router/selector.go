@@ -6,7 +6,9 @@ import (
 	"anthropic-proxy/metrics"
 	"anthropic-proxy/model"
 	"anthropic-proxy/provider"
-	"sort"
+	"fmt"
+	"regexp"
+	"sync"
 )
 
 // Selector selects the best provider for a request
@@ -14,21 +16,34 @@ type Selector struct {
 	modelRegistry *model.Registry
 	providerMgr   *provider.Manager
 	tracker       *metrics.Tracker
+	breaker       *metrics.CircuitBreaker
 	tpsThreshold  float64
+	slowLast      bool // Rank choices below the TPS threshold last instead of excluding them
+
+	mu       sync.Mutex
+	rounds   map[string]int            // Round-robin position per model name or alias
+	patterns map[string]*regexp.Regexp // Compiled routing rule patterns
 }
 
-// NewSelector creates a new provider selector
-func NewSelector(modelRegistry *model.Registry, providerMgr *provider.Manager, tracker *metrics.Tracker, tpsThreshold float64) *Selector {
+// NewSelector creates a new provider selector. Choices below tpsThreshold are excluded
+// while faster ones exist, or ranked last if slowLast is set.
+func NewSelector(modelRegistry *model.Registry, providerMgr *provider.Manager, tracker *metrics.Tracker, breaker *metrics.CircuitBreaker, tpsThreshold float64, slowLast bool) *Selector {
 	return &Selector{
 		modelRegistry: modelRegistry,
 		providerMgr:   providerMgr,
 		tracker:       tracker,
+		breaker:       breaker,
 		tpsThreshold:  tpsThreshold,
+		slowLast:      slowLast,
+		rounds:        make(map[string]int),
+		patterns:      make(map[string]*regexp.Regexp),
 	}
 }
 
-// SelectProviders returns an ordered list of providers to try for a given model
-func (s *Selector) SelectProviders(requestedModel string, thinkingEnabled bool) ([]*ProviderChoice, error) {
+// SelectProviders returns an ordered list of providers to try for a request
+func (s *Selector) SelectProviders(req Request) ([]*ProviderChoice, error) {
+	requestedModel := req.Model
+
 	// Find models matching the requested name (exact or alias match)
 	matchingModels := s.modelRegistry.FindMatching(requestedModel)
 
@@ -36,28 +51,86 @@ func (s *Selector) SelectProviders(requestedModel string, thinkingEnabled bool)
 		return nil, ErrNoModelFound
 	}
 
-	// Filter by thinking capability if thinking is enabled in the request
-	if thinkingEnabled {
-		var thinkingModels []*config.Model
+	// Keep only the providers a routing rule allows
+	if len(req.Providers) > 0 {
+		var allowed []*config.Model
+		for _, modelConfig := range matchingModels {
+			for _, name := range req.Providers {
+				if modelConfig.Provider == name {
+					allowed = append(allowed, modelConfig)
+					break
+				}
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("no model matching %s is served by providers %v", requestedModel, req.Providers)
+		}
+		matchingModels = allowed
+	}
+
+	// Drop the providers the client excluded
+	if len(req.ExcludeProviders) > 0 {
+		var allowed []*config.Model
 		for _, modelConfig := range matchingModels {
-			if modelConfig.Thinking {
-				thinkingModels = append(thinkingModels, modelConfig)
+			excluded := false
+			for _, name := range req.ExcludeProviders {
+				if modelConfig.Provider == name {
+					excluded = true
+					break
+				}
+			}
+			if !excluded {
+				allowed = append(allowed, modelConfig)
 			}
 		}
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("every provider serving %s is excluded", requestedModel)
+		}
+		matchingModels = allowed
+	}
+
+	// Drop models whose context window cannot hold the prompt and the requested output
+	if req.InputTokens > 0 {
+		fitting, err := filterContextWindow(matchingModels, req.ContextTokens())
+		if err != nil {
+			return nil, err
+		}
+		matchingModels = fitting
+	}
 
-		// Use thinking-enabled models if available, otherwise fall back to all models
-		if len(thinkingModels) > 0 {
-			matchingModels = thinkingModels
-			logger.Debug("Filtered to thinking-enabled models", "count", len(thinkingModels))
+	aliasName, alias, _ := s.modelRegistry.GetAlias(requestedModel)
+	if aliasName == "" {
+		aliasName = requestedModel
+	}
+
+	// Prefer models that support everything the request uses
+	missing := make(map[*config.Model][]string, len(matchingModels))
+	var capableModels []*config.Model
+	for _, modelConfig := range matchingModels {
+		if gaps := req.missingCapabilities(modelConfig); len(gaps) > 0 {
+			missing[modelConfig] = gaps
 		} else {
-			logger.Warn("No thinking-enabled models available, using all matching models")
+			capableModels = append(capableModels, modelConfig)
 		}
 	}
 
-	// Build list of ALL provider choices (without TPS filtering initially)
-	var allChoices []*ProviderChoice
-	var goodChoices []*ProviderChoice // Choices meeting TPS threshold
+	if len(capableModels) > 0 {
+		if len(capableModels) < len(matchingModels) {
+			logger.Debug("Filtered to models supporting the request's capabilities", "count", len(capableModels))
+		}
+		matchingModels = capableModels
+	} else if len(missing) > 0 {
+		if alias.GetCapabilityPolicy() == config.CapabilityPolicyFail {
+			return nil, &CapabilityError{Model: requestedModel, Missing: fewestMissing(matchingModels, missing)}
+		}
+		// Degrade: try every model, stripping what each one does not support
+		logger.Warn("No model supports all capabilities the request uses, degrading",
+			"model", requestedModel,
+			"required", req.Capabilities())
+	}
 
+	// Build the provider choices
+	var choices []*ProviderChoice
 	for _, modelConfig := range matchingModels {
 		prov, exists := s.providerMgr.Get(modelConfig.Provider)
 		if !exists {
@@ -67,49 +140,114 @@ func (s *Selector) SelectProviders(requestedModel string, thinkingEnabled bool)
 		// Get TPS for this provider-model combination
 		tps := s.tracker.GetTPS(prov.Name, modelConfig.Name)
 
-		choice := &ProviderChoice{
+		choices = append(choices, &ProviderChoice{
 			Provider:    prov,
 			Model:       modelConfig,
 			Weight:      modelConfig.GetWeight(),
 			TPS:         tps,
 			ActualModel: modelConfig.Name, // The actual model name to use with the provider
-		}
+			Breaker:     s.breaker.State(prov.Name, modelConfig.Name),
+			CoolingDown: !s.tracker.CooldownUntil(prov.Name, modelConfig.Name).IsZero(),
+			Saturated:   prov.Saturated(modelConfig),
+			Slow:        tps > 0 && tps < s.tpsThreshold, // No data yet gets a chance
+			Missing:     missing[modelConfig],
+		})
+	}
 
-		allChoices = append(allChoices, choice)
+	if len(choices) == 0 {
+		return nil, ErrNoProvidersAvailable
+	}
+	if !s.slowLast {
+		choices = s.excludeSlow(choices)
+	}
 
-		// Separately track choices that meet TPS threshold
-		// Exception: if TPS is 0 (no data yet), give it a chance
-		if tps == 0 || tps >= s.tpsThreshold {
-			goodChoices = append(goodChoices, choice)
+	// Order the choices with the alias's routing strategy
+	strategy := alias.GetStrategy()
+	if req.Strategy != "" {
+		strategy = req.Strategy
+	}
+	s.orderChoices(aliasName, strategy, choices)
+
+	logger.Debug("Ordered provider choices",
+		"model", requestedModel,
+		"alias", aliasName,
+		"strategy", strategy,
+		"choices", len(choices))
+
+	return choices, nil
+}
+
+// Fallback returns the next model name or alias to try when the requested one could not
+// serve a request under the given conditions. Aliases in visited are skipped, so chains
+// cannot loop; the returned alias is added to it.
+func (s *Selector) Fallback(requestedModel string, conditions []string, visited map[string]bool) (string, bool) {
+	_, alias, exists := s.modelRegistry.GetAlias(requestedModel)
+	if !exists {
+		return "", false
+	}
+	for _, fallback := range alias.FallbackAliases {
+		if visited[fallback.Alias] || !fallback.Triggers(conditions) {
+			continue
 		}
+		visited[fallback.Alias] = true
+		return fallback.Alias, true
 	}
+	return "", false
+}
 
-	if len(allChoices) == 0 {
-		return nil, ErrNoProvidersAvailable
+// excludeSlow drops the choices below the TPS threshold, unless all of them are
+func (s *Selector) excludeSlow(choices []*ProviderChoice) []*ProviderChoice {
+	var fast []*ProviderChoice
+	for _, choice := range choices {
+		if !choice.Slow {
+			fast = append(fast, choice)
+		}
+	}
+	if len(fast) > 0 {
+		return fast
 	}
 
-	// Use choices that meet threshold if available, otherwise fall back to all choices
-	var choices []*ProviderChoice
-	if len(goodChoices) > 0 {
-		choices = goodChoices
-	} else {
-		// Fallback: use all available providers even if they don't meet threshold
-		choices = allChoices
-		logger.Warn("No providers meet TPS threshold, falling back to fastest available provider",
-			"tpsThreshold", s.tpsThreshold)
-	}
-
-	// Sort by weight (descending), then TPS (descending)
-	sort.Slice(choices, func(i, j int) bool {
-		// First priority: weight
-		if choices[i].Weight != choices[j].Weight {
-			return choices[i].Weight > choices[j].Weight
+	// Fallback: use all available providers even if they don't meet threshold
+	logger.Warn("No providers meet TPS threshold, falling back to fastest available provider",
+		"tpsThreshold", s.tpsThreshold)
+	return choices
+}
+
+// filterContextWindow keeps the models whose context window holds the given number of tokens
+func filterContextWindow(models []*config.Model, tokens int) ([]*config.Model, error) {
+	var fitting []*config.Model
+	largest := 0
+	for _, modelConfig := range models {
+		// Discovered models may have no known context window; they are kept
+		if modelConfig.Context == 0 || modelConfig.Context >= tokens {
+			fitting = append(fitting, modelConfig)
+		}
+		if modelConfig.Context > largest {
+			largest = modelConfig.Context
 		}
-		// Tiebreaker: TPS
-		return choices[i].TPS > choices[j].TPS
-	})
+	}
 
-	return choices, nil
+	if len(fitting) == 0 {
+		return nil, &ContextWindowError{Tokens: tokens, Maximum: largest}
+	}
+	if len(fitting) < len(models) {
+		logger.Debug("Filtered out models with too small a context window",
+			"tokens", tokens,
+			"remaining", len(fitting),
+			"total", len(models))
+	}
+	return fitting, nil
+}
+
+// fewestMissing returns the shortest list of missing capabilities among the models
+func fewestMissing(models []*config.Model, missing map[*config.Model][]string) []string {
+	var fewest []string
+	for i, modelConfig := range models {
+		if i == 0 || len(missing[modelConfig]) < len(fewest) {
+			fewest = missing[modelConfig]
+		}
+	}
+	return fewest
 }
 
 // ProviderChoice represents a provider option with its score
@@ -119,4 +257,21 @@ type ProviderChoice struct {
 	Weight      int
 	TPS         float64
 	ActualModel string // The actual model name to send to the provider
+	Breaker     metrics.BreakerState
+	CoolingDown bool     // The provider advertised a rate limit reset that has not passed yet
+	Saturated   bool     // The provider or model is at its client-side limits
+	Slow        bool     // Measured TPS is below the threshold
+	Missing     []string // Capabilities the request uses that the model lacks; the request is degraded for it
+}
+
+// breakerRank orders breaker states from most to least usable
+func breakerRank(state metrics.BreakerState) int {
+	switch state {
+	case metrics.BreakerHalfOpen:
+		return 1
+	case metrics.BreakerOpen:
+		return 2
+	default:
+		return 0
+	}
 }
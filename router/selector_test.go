@@ -0,0 +1,57 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFilterContextWindow(t *testing.T) {
+	small := &config.Model{Name: "small", Context: 8000}
+	large := &config.Model{Name: "large", Context: 200000}
+	unknown := &config.Model{Name: "unknown"}
+
+	tests := []struct {
+		name    string
+		models  []*config.Model
+		tokens  int
+		want    []string
+		wantErr *ContextWindowError
+	}{
+		{name: "all fit", models: []*config.Model{small, large}, tokens: 4000, want: []string{"small", "large"}},
+		{name: "exactly full", models: []*config.Model{small, large}, tokens: 8000, want: []string{"small", "large"}},
+		{name: "small dropped", models: []*config.Model{small, large}, tokens: 8001, want: []string{"large"}},
+		{name: "unknown window kept", models: []*config.Model{small, unknown}, tokens: 50000, want: []string{"unknown"}},
+		{
+			name:    "none fit",
+			models:  []*config.Model{small, large},
+			tokens:  300000,
+			wantErr: &ContextWindowError{Tokens: 300000, Maximum: 200000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fitting, err := filterContextWindow(tt.models, tt.tokens)
+			if tt.wantErr != nil {
+				var windowErr *ContextWindowError
+				if !errors.As(err, &windowErr) || *windowErr != *tt.wantErr {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, modelConfig := range fitting {
+				got = append(got, modelConfig.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("models = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
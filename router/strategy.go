@@ -0,0 +1,83 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"math/rand/v2"
+	"sort"
+)
+
+// orderChoices sorts choices by availability (breaker state, rate limit cooldown and
+// saturation), then by the routing strategy. Every choice stays in the list so the
+// handler can still fail over to it.
+func (s *Selector) orderChoices(key, strategy string, choices []*ProviderChoice) {
+	// rank holds each choice's strategy score; lower is tried first
+	rank := make(map[*ProviderChoice]float64, len(choices))
+
+	switch strategy {
+	case config.StrategyWeightedRandom:
+		// Exponential race: each choice wins with probability proportional to its weight
+		for _, choice := range choices {
+			rank[choice] = rand.ExpFloat64() / float64(choice.Weight)
+		}
+
+	case config.StrategyRoundRobin:
+		// Rotate the configured order by one position per request
+		offset := s.nextRound(key)
+		for i, choice := range choices {
+			rank[choice] = float64((i - offset%len(choices) + len(choices)) % len(choices))
+		}
+
+	case config.StrategyLeastLatency:
+		// Choices without a measurement yet sort first so they get one
+		for _, choice := range choices {
+			rank[choice] = s.tracker.GetTTFTEWMA(choice.Provider.Name, choice.ActualModel).Seconds()
+		}
+
+	case config.StrategyLeastInflight:
+		for _, choice := range choices {
+			rank[choice] = float64(choice.Provider.InFlight(choice.Model))
+		}
+
+	case config.StrategyLowestCost:
+		for _, choice := range choices {
+			rank[choice] = choice.Model.InputCost + choice.Model.OutputCost
+		}
+	}
+
+	sort.SliceStable(choices, func(i, j int) bool {
+		// First priority: breaker state (closed first, open last)
+		if rankI, rankJ := breakerRank(choices[i].Breaker), breakerRank(choices[j].Breaker); rankI != rankJ {
+			return rankI < rankJ
+		}
+		// Second priority: providers waiting out a rate limit or at their client-side limits go last
+		if choices[i].CoolingDown != choices[j].CoolingDown {
+			return !choices[i].CoolingDown
+		}
+		if choices[i].Saturated != choices[j].Saturated {
+			return !choices[i].Saturated
+		}
+		// Choices below the TPS threshold follow the faster ones (with -slow-providers last)
+		if choices[i].Slow != choices[j].Slow {
+			return !choices[i].Slow
+		}
+		// Third priority: the strategy
+		if rankI, rankJ := rank[choices[i]], rank[choices[j]]; rankI != rankJ {
+			return rankI < rankJ
+		}
+		// Tiebreakers: weight, then TPS
+		if choices[i].Weight != choices[j].Weight {
+			return choices[i].Weight > choices[j].Weight
+		}
+		return choices[i].TPS > choices[j].TPS
+	})
+}
+
+// nextRound returns the round-robin position for a model name or alias and advances it
+func (s *Selector) nextRound(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	round := s.rounds[key]
+	s.rounds[key] = round + 1
+	return round
+}
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"anthropic-proxy/config"
+	"anthropic-proxy/logger"
+	"anthropic-proxy/metrics"
+	"anthropic-proxy/provider"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitQuiet("error")
+	os.Exit(m.Run())
+}
+
+// choice builds a provider choice named after its provider
+func choice(name string, weight int, tps float64) *ProviderChoice {
+	return &ProviderChoice{
+		Provider:    &provider.Provider{Name: name},
+		Model:       &config.Model{Name: "model"},
+		Weight:      weight,
+		TPS:         tps,
+		ActualModel: "model",
+		Breaker:     metrics.BreakerClosed,
+	}
+}
+
+// names lists the provider names of choices in order
+func names(choices []*ProviderChoice) []string {
+	var result []string
+	for _, c := range choices {
+		result = append(result, c.Provider.Name)
+	}
+	return result
+}
+
+func TestOrderChoices(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		choices  func() []*ProviderChoice
+		want     []string
+	}{
+		{
+			name:     "priority by weight then TPS",
+			strategy: config.StrategyPriority,
+			choices: func() []*ProviderChoice {
+				return []*ProviderChoice{choice("low", 1, 100), choice("slower", 5, 50), choice("faster", 5, 80)}
+			},
+			want: []string{"faster", "slower", "low"},
+		},
+		{
+			name:     "open breaker goes last",
+			strategy: config.StrategyPriority,
+			choices: func() []*ProviderChoice {
+				open := choice("open", 10, 100)
+				open.Breaker = metrics.BreakerOpen
+				halfOpen := choice("half-open", 10, 100)
+				halfOpen.Breaker = metrics.BreakerHalfOpen
+				return []*ProviderChoice{open, halfOpen, choice("closed", 1, 100)}
+			},
+			want: []string{"closed", "half-open", "open"},
+		},
+		{
+			name:     "cooling down, saturated and slow follow the others",
+			strategy: config.StrategyPriority,
+			choices: func() []*ProviderChoice {
+				cooling := choice("cooling", 10, 100)
+				cooling.CoolingDown = true
+				saturated := choice("saturated", 10, 100)
+				saturated.Saturated = true
+				slow := choice("slow", 10, 10)
+				slow.Slow = true
+				return []*ProviderChoice{cooling, saturated, slow, choice("ready", 1, 100)}
+			},
+			want: []string{"ready", "slow", "saturated", "cooling"},
+		},
+		{
+			name:     "lowest cost",
+			strategy: config.StrategyLowestCost,
+			choices: func() []*ProviderChoice {
+				expensive, cheap := choice("expensive", 10, 100), choice("cheap", 1, 100)
+				expensive.Model = &config.Model{Name: "model", InputCost: 3, OutputCost: 15}
+				cheap.Model = &config.Model{Name: "model", InputCost: 1, OutputCost: 5}
+				return []*ProviderChoice{expensive, cheap}
+			},
+			want: []string{"cheap", "expensive"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSelector(nil, nil, nil, nil, 40, false)
+			choices := tt.choices()
+			s.orderChoices("alias", tt.strategy, choices)
+			if got := names(choices); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderChoicesRoundRobin(t *testing.T) {
+	s := NewSelector(nil, nil, nil, nil, 40, false)
+	want := [][]string{{"a", "b", "c"}, {"b", "c", "a"}, {"c", "a", "b"}, {"a", "b", "c"}}
+	for round, order := range want {
+		choices := []*ProviderChoice{choice("a", 1, 0), choice("b", 1, 0), choice("c", 1, 0)}
+		s.orderChoices("alias", config.StrategyRoundRobin, choices)
+		if got := names(choices); !slices.Equal(got, order) {
+			t.Errorf("round %d: order = %v, want %v", round, got, order)
+		}
+	}
+}
+
+func TestExcludeSlow(t *testing.T) {
+	slow := func(name string) *ProviderChoice {
+		c := choice(name, 1, 10)
+		c.Slow = true
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		choices []*ProviderChoice
+		want    []string
+	}{
+		{name: "slow dropped", choices: []*ProviderChoice{slow("slow"), choice("fast", 1, 100)}, want: []string{"fast"}},
+		{name: "all slow kept", choices: []*ProviderChoice{slow("a"), slow("b")}, want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSelector(nil, nil, nil, nil, 40, false)
+			if got := names(s.excludeSlow(tt.choices)); !slices.Equal(got, tt.want) {
+				t.Errorf("choices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
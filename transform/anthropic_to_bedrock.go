@@ -0,0 +1,68 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BedrockAnthropicVersion is the API version Bedrock expects in the request body
+const BedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// AnthropicToBedrockRequest prepares an Anthropic request for the Bedrock InvokeModel API.
+// Bedrock takes the model and streaming mode from the URL and the API version and beta
+// flags from the body, so the request path is returned too.
+func AnthropicToBedrockRequest(anthropicBody []byte, betas string) ([]byte, string, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(anthropicBody, &request); err != nil {
+		return nil, "", fmt.Errorf("failed to parse Anthropic request: %w", err)
+	}
+
+	model, _ := request["model"].(string)
+	stream, _ := request["stream"].(bool)
+	delete(request, "model")
+	delete(request, "stream")
+	request["anthropic_version"] = BedrockAnthropicVersion
+
+	// Bedrock ignores the anthropic-beta header and reads the flags from the body instead
+	if betas != "" {
+		var betaList []string
+		for _, beta := range strings.Split(betas, ",") {
+			if beta = strings.TrimSpace(beta); beta != "" {
+				betaList = append(betaList, beta)
+			}
+		}
+		request["anthropic_beta"] = betaList
+	}
+
+	bedrockBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal Bedrock request: %w", err)
+	}
+
+	return bedrockBody, BedrockRequestPath(model, stream), nil
+}
+
+// BedrockRequestPath returns the InvokeModel path for a model ID, inference profile or ARN
+func BedrockRequestPath(model string, stream bool) string {
+	if stream {
+		return "/model/" + AWSURIEncode(model) + "/invoke-with-response-stream"
+	}
+	return "/model/" + AWSURIEncode(model) + "/invoke"
+}
+
+// AWSURIEncode percent-encodes everything except unreserved characters, as SigV4 requires.
+// Model ARNs contain ':' and '/', which url.PathEscape would leave alone or mangle.
+func AWSURIEncode(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
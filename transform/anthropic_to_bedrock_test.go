@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAnthropicToBedrockRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   string
+		betas     string
+		wantPath  string
+		wantBetas interface{} // anthropic_beta in the body, nil if absent
+	}{
+		{
+			name:     "model id",
+			request:  `{"model":"anthropic.claude-sonnet-4-20250514-v1:0","max_tokens":10}`,
+			wantPath: "/model/anthropic.claude-sonnet-4-20250514-v1%3A0/invoke",
+		},
+		{
+			name:     "inference profile ARN streaming",
+			request:  `{"model":"arn:aws:bedrock:us-east-1:123:inference-profile/us.claude","max_tokens":10,"stream":true}`,
+			wantPath: "/model/arn%3Aaws%3Abedrock%3Aus-east-1%3A123%3Ainference-profile%2Fus.claude/invoke-with-response-stream",
+		},
+		{
+			name:      "beta flags move into the body",
+			request:   `{"model":"m","max_tokens":10}`,
+			betas:     "tools-2024, ,context-1m",
+			wantPath:  "/model/m/invoke",
+			wantBetas: []interface{}{"tools-2024", "context-1m"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, path, err := AnthropicToBedrockRequest([]byte(tt.request), tt.betas)
+			if err != nil {
+				t.Fatalf("AnthropicToBedrockRequest: %v", err)
+			}
+			if path != tt.wantPath {
+				t.Errorf("path = %s, want %s", path, tt.wantPath)
+			}
+
+			var converted map[string]interface{}
+			if err := json.Unmarshal(body, &converted); err != nil {
+				t.Fatalf("invalid Bedrock request %s: %v", body, err)
+			}
+			if converted["anthropic_version"] != BedrockAnthropicVersion {
+				t.Errorf("anthropic_version = %v, want %s", converted["anthropic_version"], BedrockAnthropicVersion)
+			}
+			for _, field := range []string{"model", "stream"} {
+				if _, ok := converted[field]; ok {
+					t.Errorf("%s was left in the body", field)
+				}
+			}
+			if !reflect.DeepEqual(converted["anthropic_beta"], tt.wantBetas) {
+				t.Errorf("anthropic_beta = %v, want %v", converted["anthropic_beta"], tt.wantBetas)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+)
+
+// AnthropicToGeminiRequest converts an Anthropic request to a Gemini generateContent request.
+// Gemini puts the model and the streaming mode in the URL, so the request path is returned too.
+func AnthropicToGeminiRequest(anthropicBody []byte) ([]byte, string, error) {
+	var anthropicReq AnthropicRequest
+	if err := json.Unmarshal(anthropicBody, &anthropicReq); err != nil {
+		return nil, "", fmt.Errorf("failed to parse Anthropic request: %w", err)
+	}
+
+	// Function responses are matched to calls by name, so remember the name of every tool_use ID
+	toolNames := make(map[string]string)
+
+	// Convert messages
+	contents := make([]GeminiContent, 0, len(anthropicReq.Messages))
+	for _, msg := range anthropicReq.Messages {
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+
+		parts, err := convertContentToGeminiParts(msg.Content, toolNames)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		contents = append(contents, GeminiContent{
+			Role:  role,
+			Parts: parts,
+		})
+	}
+
+	geminiReq := GeminiRequest{
+		Contents: contents,
+		GenerationConfig: &GeminiGenerationConfig{
+			MaxOutputTokens: anthropicReq.MaxTokens,
+			Temperature:     anthropicReq.Temperature,
+			TopP:            anthropicReq.TopP,
+			TopK:            anthropicReq.TopK,
+			StopSequences:   anthropicReq.StopSeq,
+		},
+	}
+
+	// Add system instruction if present
+	if systemText := extractSystemText(anthropicReq.System); systemText != "" {
+		geminiReq.SystemInstruction = &GeminiContent{
+			Parts: []GeminiPart{{Text: systemText}},
+		}
+	}
+
+	// Convert tools to function declarations
+	if len(anthropicReq.Tools) > 0 {
+		geminiReq.Tools = convertToolsToGemini(anthropicReq.Tools)
+	}
+
+	// Convert tool_choice if present
+	if anthropicReq.ToolChoice != nil {
+		geminiReq.ToolConfig = convertToolChoiceToGemini(anthropicReq.ToolChoice)
+	}
+
+	// Convert extended thinking to a thinking budget, asking for thought summaries
+	if budget, ok := thinkingBudget(anthropicReq.Thinking); ok {
+		geminiReq.GenerationConfig.ThinkingConfig = &GeminiThinkingConfig{
+			ThinkingBudget:  budget,
+			IncludeThoughts: true,
+		}
+	}
+
+	// Serialize to JSON
+	geminiBody, err := json.Marshal(geminiReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal Gemini request: %w", err)
+	}
+
+	return geminiBody, GeminiRequestPath(anthropicReq.Model, anthropicReq.Stream), nil
+}
+
+// GeminiRequestPath returns the generateContent path for a model
+func GeminiRequestPath(model string, stream bool) string {
+	if stream {
+		return "/v1beta/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse"
+	}
+	return "/v1beta/models/" + url.PathEscape(model) + ":generateContent"
+}
+
+// convertContentToGeminiParts converts Anthropic message content to Gemini parts.
+// Thinking blocks are dropped since Gemini cannot accept them without its own signatures.
+func convertContentToGeminiParts(content interface{}, toolNames map[string]string) ([]GeminiPart, error) {
+	if text, ok := content.(string); ok {
+		if text == "" {
+			return nil, nil
+		}
+		return []GeminiPart{{Text: text}}, nil
+	}
+
+	var parts []GeminiPart
+	for _, block := range contentBlocks(content) {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		blockType, _ := blockMap["type"].(string)
+		switch blockType {
+		case "tool_use":
+			id, _ := blockMap["id"].(string)
+			name, _ := blockMap["name"].(string)
+			toolNames[id] = name
+			args, _ := blockMap["input"].(map[string]interface{})
+			parts = append(parts, GeminiPart{
+				FunctionCall: &GeminiFunctionCall{
+					Name: name,
+					Args: args,
+				},
+			})
+
+		case "tool_result":
+			toolUseID, _ := blockMap["tool_use_id"].(string)
+			output, imageParts, err := convertToolResultContent(blockMap["content"])
+			if err != nil {
+				return nil, err
+			}
+
+			response := map[string]interface{}{"content": output}
+			if isError, _ := blockMap["is_error"].(bool); isError {
+				response = map[string]interface{}{"error": output}
+			}
+			parts = append(parts, GeminiPart{
+				FunctionResponse: &GeminiFunctionResponse{
+					Name:     toolNames[toolUseID],
+					Response: response,
+				},
+			})
+
+			// Images returned by tools follow the function response as regular parts
+			for _, imagePart := range imageParts {
+				imageURL, _ := imagePart.(map[string]interface{})["image_url"].(map[string]interface{})
+				urlStr, _ := imageURL["url"].(string)
+				parts = append(parts, geminiMediaPart(urlToAnthropicSource(urlStr), ""))
+			}
+
+		default:
+			converted, err := convertContentBlockToGemini(blockMap)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, converted...)
+		}
+	}
+
+	return parts, nil
+}
+
+// convertContentBlockToGemini converts a text, image or document block to Gemini parts
+func convertContentBlockToGemini(block map[string]interface{}) ([]GeminiPart, error) {
+	blockType, _ := block["type"].(string)
+
+	switch blockType {
+	case "text":
+		text, _ := block["text"].(string)
+		if text == "" {
+			return nil, nil
+		}
+		return []GeminiPart{{Text: text}}, nil
+
+	case "image":
+		source, ok := block["source"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: image block is missing a source", ErrUnsupportedContent)
+		}
+		return []GeminiPart{geminiMediaPart(source, "")}, nil
+
+	case "document":
+		source, ok := block["source"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: document block is missing a source", ErrUnsupportedContent)
+		}
+
+		sourceType, _ := source["type"].(string)
+		switch sourceType {
+		case "text":
+			data, _ := source["data"].(string)
+			if title, _ := block["title"].(string); title != "" {
+				data = title + "\n\n" + data
+			}
+			return []GeminiPart{{Text: data}}, nil
+		case "base64", "url":
+			return []GeminiPart{geminiMediaPart(source, "application/pdf")}, nil
+		case "content":
+			var parts []GeminiPart
+			if text, ok := source["content"].(string); ok && text != "" {
+				parts = append(parts, GeminiPart{Text: text})
+			}
+			for _, inner := range contentBlocks(source["content"]) {
+				if innerMap, ok := inner.(map[string]interface{}); ok {
+					converted, err := convertContentBlockToGemini(innerMap)
+					if err != nil {
+						return nil, err
+					}
+					parts = append(parts, converted...)
+				}
+			}
+			return parts, nil
+		default:
+			return nil, fmt.Errorf("%w: document source type %q is not supported by Gemini providers", ErrUnsupportedContent, sourceType)
+		}
+	}
+
+	return nil, nil
+}
+
+// geminiMediaPart converts an Anthropic base64 or url source to inline data or file data
+func geminiMediaPart(source map[string]interface{}, defaultMimeType string) GeminiPart {
+	mimeType, _ := source["media_type"].(string)
+
+	if sourceType, _ := source["type"].(string); sourceType == "url" {
+		fileURI, _ := source["url"].(string)
+		if mimeType == "" {
+			mimeType = mimeTypeFromURL(fileURI, defaultMimeType)
+		}
+		return GeminiPart{
+			FileData: &GeminiFileData{
+				MimeType: mimeType,
+				FileURI:  fileURI,
+			},
+		}
+	}
+
+	if mimeType == "" {
+		mimeType = defaultMimeType
+	}
+	data, _ := source["data"].(string)
+	return GeminiPart{
+		InlineData: &GeminiBlob{
+			MimeType: mimeType,
+			Data:     data,
+		},
+	}
+}
+
+// mimeTypeFromURL guesses a mime type from the file extension of a URL
+func mimeTypeFromURL(fileURI, defaultMimeType string) string {
+	if parsed, err := url.Parse(fileURI); err == nil {
+		if mimeType := mime.TypeByExtension(path.Ext(parsed.Path)); mimeType != "" {
+			return mimeType
+		}
+	}
+	if defaultMimeType == "" {
+		return "image/jpeg"
+	}
+	return defaultMimeType
+}
+
+// convertToolsToGemini converts Anthropic tools to Gemini function declarations
+// Anthropic: {"name": "...", "description": "...", "input_schema": {...}}
+// Gemini: {"functionDeclarations": [{"name": "...", "description": "...", "parametersJsonSchema": {...}}]}
+func convertToolsToGemini(anthropicTools []interface{}) []GeminiTool {
+	declarations := make([]GeminiFunctionDeclaration, 0, len(anthropicTools))
+	seenNames := make(map[string]struct{}, len(anthropicTools))
+
+	for _, tool := range anthropicTools {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := toolMap["name"].(string)
+		if _, exists := seenNames[name]; exists {
+			continue
+		}
+		seenNames[name] = struct{}{}
+
+		description, _ := toolMap["description"].(string)
+		declarations = append(declarations, GeminiFunctionDeclaration{
+			Name:                 name,
+			Description:          description,
+			ParametersJSONSchema: toolMap["input_schema"],
+		})
+	}
+
+	if len(declarations) == 0 {
+		return nil
+	}
+	return []GeminiTool{{FunctionDeclarations: declarations}}
+}
+
+// convertToolChoiceToGemini converts Anthropic tool_choice to a Gemini function calling config
+func convertToolChoiceToGemini(anthropicToolChoice interface{}) *GeminiToolConfig {
+	toolChoiceMap, ok := anthropicToolChoice.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	config := GeminiFunctionCallingConfig{Mode: "AUTO"}
+	switch toolChoiceMap["type"] {
+	case "any":
+		config.Mode = "ANY"
+	case "none":
+		config.Mode = "NONE"
+	case "tool":
+		if name, ok := toolChoiceMap["name"].(string); ok {
+			config.Mode = "ANY"
+			config.AllowedFunctionNames = []string{name}
+		}
+	}
+
+	return &GeminiToolConfig{FunctionCallingConfig: config}
+}
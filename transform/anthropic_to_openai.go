@@ -2,12 +2,28 @@ package transform
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// ErrUnsupportedContent is returned when a request contains content blocks that
+// have no equivalent in the target provider format
+var ErrUnsupportedContent = errors.New("unsupported content")
+
+// OpenAIRequestOptions holds per-provider settings for request conversion
+type OpenAIRequestOptions struct {
+	// ReasoningFormat selects how a thinking budget is sent: "effort" (default),
+	// "reasoning" or "none"
+	ReasoningFormat string
+
+	// OmitStreamUsage leaves out stream_options.include_usage for providers that reject it
+	OmitStreamUsage bool
+}
+
 // AnthropicToOpenAIRequest converts an Anthropic request to OpenAI format
-func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
+func AnthropicToOpenAIRequest(anthropicBody []byte, opts OpenAIRequestOptions) ([]byte, error) {
 	var anthropicReq AnthropicRequest
 	if err := json.Unmarshal(anthropicBody, &anthropicReq); err != nil {
 		return nil, fmt.Errorf("failed to parse Anthropic request: %w", err)
@@ -64,7 +80,7 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 				json.Unmarshal(data, &blocks)
 			}
 
-			var textContent string
+			var userParts []interface{}
 			for _, block := range blocks {
 				blockMap, ok := block.(map[string]interface{})
 				if !ok {
@@ -76,16 +92,11 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 				if blockType == "tool_result" {
 					// Create a tool message for this tool_result
 					toolUseID, _ := blockMap["tool_use_id"].(string)
-					content := blockMap["content"]
-
-					// Convert content to string
-					var contentStr string
-					switch v := content.(type) {
-					case string:
-						contentStr = v
-					default:
-						contentBytes, _ := json.Marshal(content)
-						contentStr = string(contentBytes)
+
+					// OpenAI tool messages only carry text, so images move to the following user message
+					contentStr, imageParts, err := convertToolResultContent(blockMap["content"])
+					if err != nil {
+						return nil, err
 					}
 
 					toolMsg := OpenAIMessage{
@@ -94,27 +105,40 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 						Content:    contentStr,
 					}
 					openaiMessages = append(openaiMessages, toolMsg)
-				} else if blockType == "text" {
-					// Collect text content
-					if text, ok := blockMap["text"].(string); ok {
-						if textContent != "" {
-							textContent += "\n"
-						}
-						textContent += text
+					userParts = append(userParts, imageParts...)
+				} else {
+					parts, err := convertContentBlockToOpenAI(blockMap)
+					if err != nil {
+						return nil, err
 					}
+					userParts = append(userParts, parts...)
 				}
 			}
 
-			// If there was text content, add it as a user message
-			if textContent != "" {
+			// If there was other content, add it as a user message
+			if len(userParts) > 0 {
 				openaiMessages = append(openaiMessages, OpenAIMessage{
 					Role:    "user",
-					Content: textContent,
+					Content: collapseOpenAIParts(userParts),
 				})
 			}
 			continue
 		}
 
+		// Handle user messages with images or documents
+		if msg.Role == "user" && hasMediaContent(msg.Content) {
+			content, err := convertContentToOpenAI(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+
+			openaiMessages = append(openaiMessages, OpenAIMessage{
+				Role:    "user",
+				Content: content,
+			})
+			continue
+		}
+
 		// Handle regular messages (text only)
 		openaiMsg := OpenAIMessage{
 			Role: msg.Role,
@@ -137,6 +161,11 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 		Stream:      anthropicReq.Stream,
 	}
 
+	// Ask for a final usage chunk so streamed responses report real token counts
+	if anthropicReq.Stream && !opts.OmitStreamUsage {
+		openaiReq.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+
 	// Convert stop sequences
 	if len(anthropicReq.StopSeq) > 0 {
 		if len(anthropicReq.StopSeq) == 1 {
@@ -156,6 +185,19 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 		openaiReq.ToolChoice = convertToolChoiceToOpenAI(anthropicReq.ToolChoice)
 	}
 
+	// Convert extended thinking to the provider's reasoning parameter
+	if budget, ok := thinkingBudget(anthropicReq.Thinking); ok {
+		switch opts.ReasoningFormat {
+		case "none":
+		case "reasoning":
+			openaiReq.Reasoning = map[string]interface{}{
+				"max_tokens": budget,
+			}
+		default:
+			openaiReq.ReasoningEffort = reasoningEffortForBudget(budget)
+		}
+	}
+
 	// Serialize to JSON
 	openaiBody, err := json.Marshal(openaiReq)
 	if err != nil {
@@ -165,6 +207,34 @@ func AnthropicToOpenAIRequest(anthropicBody []byte) ([]byte, error) {
 	return openaiBody, nil
 }
 
+// thinkingBudget returns the budget_tokens of an enabled Anthropic thinking config
+func thinkingBudget(thinking *map[string]interface{}) (int, bool) {
+	if thinking == nil {
+		return 0, false
+	}
+
+	if thinkingType, _ := (*thinking)["type"].(string); thinkingType != "enabled" {
+		return 0, false
+	}
+
+	budget, _ := (*thinking)["budget_tokens"].(float64)
+	return int(budget), true
+}
+
+// reasoningEffortForBudget maps an Anthropic thinking budget to an OpenAI reasoning_effort level
+func reasoningEffortForBudget(budget int) string {
+	switch {
+	case budget <= 0:
+		return "medium" // No budget given, use the OpenAI default
+	case budget < 4096:
+		return "low"
+	case budget < 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
 // extractSystemText extracts text from Anthropic system field
 // System can be a string or an array of content blocks
 func extractSystemText(system interface{}) string {
@@ -385,6 +455,248 @@ func convertToolUseToOpenAI(content interface{}) []interface{} {
 	return toolCalls
 }
 
+// convertContentToOpenAI converts Anthropic content into OpenAI message content.
+// Text-only content stays a plain string; anything with images or documents
+// becomes an array of content parts.
+func convertContentToOpenAI(content interface{}) (interface{}, error) {
+	blocks := contentBlocks(content)
+	if blocks == nil {
+		return extractTextContent(content), nil
+	}
+
+	var parts []interface{}
+	for _, block := range blocks {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		converted, err := convertContentBlockToOpenAI(blockMap)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, converted...)
+	}
+
+	return collapseOpenAIParts(parts), nil
+}
+
+// convertContentBlockToOpenAI converts a single Anthropic content block into OpenAI content parts.
+// Blocks without an OpenAI equivalent (e.g. thinking) are dropped.
+func convertContentBlockToOpenAI(block map[string]interface{}) ([]interface{}, error) {
+	blockType, _ := block["type"].(string)
+
+	switch blockType {
+	case "text":
+		text, _ := block["text"].(string)
+		if text == "" {
+			return nil, nil
+		}
+		return []interface{}{openAITextPart(text)}, nil
+
+	case "image":
+		url, err := imageSourceToURL(block["source"])
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{map[string]interface{}{
+			"type": "image_url",
+			"image_url": map[string]interface{}{
+				"url": url,
+			},
+		}}, nil
+
+	case "document":
+		return convertDocumentToOpenAI(block)
+	}
+
+	return nil, nil
+}
+
+// imageSourceToURL converts an Anthropic image source into a URL usable in an OpenAI image_url part
+// Anthropic: {"type": "base64", "media_type": "image/png", "data": "..."} or {"type": "url", "url": "..."}
+// OpenAI: "data:image/png;base64,..." or the plain URL
+func imageSourceToURL(source interface{}) (string, error) {
+	sourceMap, ok := source.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("%w: image block is missing a source", ErrUnsupportedContent)
+	}
+
+	sourceType, _ := sourceMap["type"].(string)
+	switch sourceType {
+	case "base64":
+		mediaType, _ := sourceMap["media_type"].(string)
+		data, _ := sourceMap["data"].(string)
+		if mediaType == "" || data == "" {
+			return "", fmt.Errorf("%w: base64 image source requires media_type and data", ErrUnsupportedContent)
+		}
+		return "data:" + mediaType + ";base64," + data, nil
+	case "url":
+		url, _ := sourceMap["url"].(string)
+		if url == "" {
+			return "", fmt.Errorf("%w: url image source requires a url", ErrUnsupportedContent)
+		}
+		return url, nil
+	default:
+		return "", fmt.Errorf("%w: image source type %q is not supported by OpenAI providers", ErrUnsupportedContent, sourceType)
+	}
+}
+
+// convertDocumentToOpenAI maps an Anthropic document block to the nearest OpenAI content parts:
+// plain text documents become text parts, base64 PDFs become file parts, and
+// custom content documents are converted block by block
+func convertDocumentToOpenAI(block map[string]interface{}) ([]interface{}, error) {
+	sourceMap, ok := block["source"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: document block is missing a source", ErrUnsupportedContent)
+	}
+
+	title, _ := block["title"].(string)
+	sourceType, _ := sourceMap["type"].(string)
+
+	switch sourceType {
+	case "text":
+		data, _ := sourceMap["data"].(string)
+		if title != "" {
+			data = title + "\n\n" + data
+		}
+		return []interface{}{openAITextPart(data)}, nil
+
+	case "base64":
+		mediaType, _ := sourceMap["media_type"].(string)
+		data, _ := sourceMap["data"].(string)
+		if mediaType != "application/pdf" {
+			return nil, fmt.Errorf("%w: document media type %q is not supported by OpenAI providers", ErrUnsupportedContent, mediaType)
+		}
+		filename := title
+		if filename == "" {
+			filename = "document.pdf"
+		}
+		return []interface{}{map[string]interface{}{
+			"type": "file",
+			"file": map[string]interface{}{
+				"filename":  filename,
+				"file_data": "data:" + mediaType + ";base64," + data,
+			},
+		}}, nil
+
+	case "content":
+		var parts []interface{}
+		for _, inner := range contentBlocks(sourceMap["content"]) {
+			innerMap, ok := inner.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			converted, err := convertContentBlockToOpenAI(innerMap)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, converted...)
+		}
+		if text, ok := sourceMap["content"].(string); ok && text != "" {
+			parts = append(parts, openAITextPart(text))
+		}
+		return parts, nil
+
+	default:
+		return nil, fmt.Errorf("%w: document source type %q is not supported by OpenAI providers", ErrUnsupportedContent, sourceType)
+	}
+}
+
+// convertToolResultContent splits tool_result content into the text for the tool
+// message and any image parts, which OpenAI only accepts in user messages
+func convertToolResultContent(content interface{}) (string, []interface{}, error) {
+	if str, ok := content.(string); ok {
+		return str, nil, nil
+	}
+
+	blocks := contentBlocks(content)
+	if blocks == nil {
+		if content == nil {
+			return "", nil, nil
+		}
+		contentBytes, _ := json.Marshal(content)
+		return string(contentBytes), nil, nil
+	}
+
+	var texts []string
+	var imageParts []interface{}
+	for _, block := range blocks {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		parts, err := convertContentBlockToOpenAI(blockMap)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, part := range parts {
+			partMap := part.(map[string]interface{})
+			if partMap["type"] == "text" {
+				texts = append(texts, partMap["text"].(string))
+			} else {
+				imageParts = append(imageParts, part)
+			}
+		}
+	}
+
+	return strings.Join(texts, "\n"), imageParts, nil
+}
+
+// collapseOpenAIParts returns a plain string when all parts are text, and the parts array otherwise
+func collapseOpenAIParts(parts []interface{}) interface{} {
+	var texts []string
+	for _, part := range parts {
+		partMap, ok := part.(map[string]interface{})
+		if !ok || partMap["type"] != "text" {
+			return parts
+		}
+		texts = append(texts, partMap["text"].(string))
+	}
+	return strings.Join(texts, "\n")
+}
+
+func openAITextPart(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"text": text,
+	}
+}
+
+// contentBlocks returns content as a slice of blocks, or nil if it is not an array
+func contentBlocks(content interface{}) []interface{} {
+	switch v := content.(type) {
+	case []interface{}:
+		return v
+	case string, nil:
+		return nil
+	default:
+		var blocks []interface{}
+		data, err := json.Marshal(content)
+		if err != nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			return nil
+		}
+		return blocks
+	}
+}
+
+// hasMediaContent checks if content contains image or document blocks
+func hasMediaContent(content interface{}) bool {
+	for _, block := range contentBlocks(content) {
+		if blockMap, ok := block.(map[string]interface{}); ok {
+			if blockType, ok := blockMap["type"].(string); ok && (blockType == "image" || blockType == "document") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // hasToolUse checks if content contains tool_use blocks
 func hasToolUse(content interface{}) bool {
 	var blocks []interface{}
@@ -426,3 +738,240 @@ func hasToolResult(content interface{}) bool {
 
 	return false
 }
+
+// AnthropicToOpenAIResponse converts an Anthropic response to an OpenAI chat completion
+func AnthropicToOpenAIResponse(anthropicBody []byte, model string) ([]byte, error) {
+	var anthropicResp AnthropicResponse
+	if err := json.Unmarshal(anthropicBody, &anthropicResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	message := OpenAIMessage{
+		Role: "assistant",
+	}
+
+	// Text becomes content, thinking becomes reasoning_content, tool_use becomes tool_calls
+	var texts, thinking []string
+	for _, block := range anthropicResp.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "thinking":
+			thinking = append(thinking, block.Thinking)
+		case "tool_use":
+			argsBytes, _ := json.Marshal(block.Input)
+			message.ToolCalls = append(message.ToolCalls, map[string]interface{}{
+				"id":   block.ID,
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      block.Name,
+					"arguments": string(argsBytes),
+				},
+			})
+		}
+	}
+	if len(texts) > 0 {
+		message.Content = strings.Join(texts, "")
+	}
+	message.ReasoningContent = strings.Join(thinking, "")
+
+	openaiResp := OpenAIResponse{
+		ID:      anthropicResp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{{
+			Index:        0,
+			Message:      message,
+			FinishReason: mapAnthropicStopReason(anthropicResp.StopReason),
+		}},
+		Usage: anthropicUsageToOpenAI(anthropicResp.Usage),
+	}
+
+	openaiBody, err := json.Marshal(openaiResp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAI response: %w", err)
+	}
+
+	return openaiBody, nil
+}
+
+// AnthropicToOpenAIStreamConverter converts Anthropic SSE events into OpenAI chat
+// completion chunks. Tool calls are numbered in the order their blocks start,
+// and the returned chunks end with "[DONE]" once message_stop is seen.
+type AnthropicToOpenAIStreamConverter struct {
+	model        string
+	includeUsage bool
+	id           string
+	created      int64
+	usage        AnthropicUsage
+	toolIndices  map[int]int // Anthropic block index -> OpenAI tool_call index
+	finished     bool
+}
+
+// NewAnthropicToOpenAIStreamConverter creates a converter for a single Anthropic stream
+func NewAnthropicToOpenAIStreamConverter(model string, includeUsage bool) *AnthropicToOpenAIStreamConverter {
+	return &AnthropicToOpenAIStreamConverter{
+		model:        model,
+		includeUsage: includeUsage,
+		id:           GenerateOpenAICompletionID(),
+		created:      time.Now().Unix(),
+		toolIndices:  make(map[int]int),
+	}
+}
+
+// Convert converts one Anthropic stream event into zero or more OpenAI chunks
+func (s *AnthropicToOpenAIStreamConverter) Convert(anthropicEvent []byte) ([]string, error) {
+	var event AnthropicStreamEvent
+	if err := json.Unmarshal(anthropicEvent, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic stream event: %w", err)
+	}
+
+	if s.finished {
+		return nil, nil
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			s.usage = event.Message.Usage
+		}
+		return []string{s.chunk(OpenAIStreamDelta{Role: "assistant", Content: ""}, nil)}, nil
+
+	case "content_block_start":
+		if event.ContentBlock == nil || event.ContentBlock.Type != "tool_use" {
+			return nil, nil
+		}
+		toolIndex := len(s.toolIndices)
+		s.toolIndices[event.BlockIndex()] = toolIndex
+		return []string{s.chunk(OpenAIStreamDelta{
+			ToolCalls: []OpenAIStreamToolCall{{
+				Index: toolIndex,
+				ID:    event.ContentBlock.ID,
+				Type:  "function",
+				Function: OpenAIStreamFunctionCall{
+					Name: event.ContentBlock.Name,
+				},
+			}},
+		}, nil)}, nil
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil, nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			return []string{s.chunk(OpenAIStreamDelta{Content: event.Delta.Text}, nil)}, nil
+		case "thinking_delta":
+			return []string{s.chunk(OpenAIStreamDelta{ReasoningContent: event.Delta.Thinking}, nil)}, nil
+		case "input_json_delta":
+			toolIndex, ok := s.toolIndices[event.BlockIndex()]
+			if !ok {
+				return nil, nil
+			}
+			return []string{s.chunk(OpenAIStreamDelta{
+				ToolCalls: []OpenAIStreamToolCall{{
+					Index: toolIndex,
+					Function: OpenAIStreamFunctionCall{
+						Arguments: event.Delta.PartialJSON,
+					},
+				}},
+			}, nil)}, nil
+		}
+		return nil, nil
+
+	case "message_delta":
+		// message_delta usage is cumulative and may include updated input counts
+		if event.Usage != nil {
+			s.usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				s.usage.InputTokens = event.Usage.InputTokens
+			}
+		}
+		if event.Delta == nil || event.Delta.StopReason == "" {
+			return nil, nil
+		}
+		finishReason := mapAnthropicStopReason(event.Delta.StopReason)
+		return []string{s.chunk(OpenAIStreamDelta{}, &finishReason)}, nil
+
+	case "message_stop":
+		s.finished = true
+		var chunks []string
+		if s.includeUsage {
+			usage := anthropicUsageToOpenAI(s.usage)
+			data, _ := json.Marshal(OpenAIStreamChunk{
+				ID:      s.id,
+				Object:  "chat.completion.chunk",
+				Created: s.created,
+				Model:   s.model,
+				Choices: []OpenAIStreamChoice{},
+				Usage:   &usage,
+			})
+			chunks = append(chunks, string(data))
+		}
+		return append(chunks, "[DONE]"), nil
+
+	case "error":
+		// Forward upstream errors in the shape OpenAI uses for stream errors
+		var errorEvent map[string]interface{}
+		json.Unmarshal(anthropicEvent, &errorEvent)
+		data, _ := json.Marshal(map[string]interface{}{
+			"error": errorEvent["error"],
+		})
+		s.finished = true
+		return []string{string(data), "[DONE]"}, nil
+	}
+
+	return nil, nil
+}
+
+// chunk marshals a single-choice OpenAI stream chunk
+func (s *AnthropicToOpenAIStreamConverter) chunk(delta OpenAIStreamDelta, finishReason *string) string {
+	data, _ := json.Marshal(OpenAIStreamChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []OpenAIStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+	})
+	return string(data)
+}
+
+// mapAnthropicStopReason maps an Anthropic stop_reason to an OpenAI finish_reason
+func mapAnthropicStopReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// anthropicUsageToOpenAI converts usage; OpenAI prompt tokens include cached tokens
+func anthropicUsageToOpenAI(usage AnthropicUsage) OpenAIUsage {
+	promptTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	openaiUsage := OpenAIUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      promptTokens + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 {
+		openaiUsage.PromptTokensDetails = &OpenAIPromptTokensDetails{
+			CachedTokens: usage.CacheReadInputTokens,
+		}
+	}
+	return openaiUsage
+}
+
+// GenerateOpenAICompletionID generates an OpenAI-style chat completion ID
+func GenerateOpenAICompletionID() string {
+	return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+}
@@ -0,0 +1,290 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// convertedField converts an Anthropic request to OpenAI format and returns one top-level field
+func convertedField(t *testing.T, request string, opts OpenAIRequestOptions, field string) interface{} {
+	t.Helper()
+
+	body, err := AnthropicToOpenAIRequest([]byte(request), opts)
+	if err != nil {
+		t.Fatalf("AnthropicToOpenAIRequest: %v", err)
+	}
+	var converted map[string]interface{}
+	if err := json.Unmarshal(body, &converted); err != nil {
+		t.Fatalf("invalid OpenAI request %s: %v", body, err)
+	}
+	return converted[field]
+}
+
+// decodeJSON decodes an expected value written as JSON
+func decodeJSON(t *testing.T, value string) interface{} {
+	t.Helper()
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		t.Fatalf("invalid expected JSON %s: %v", value, err)
+	}
+	return decoded
+}
+
+func TestAnthropicToOpenAIRequestMedia(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages string
+		want     string // Expected OpenAI messages
+	}{
+		{
+			name:     "text blocks collapse to a string",
+			messages: `[{"role":"user","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}]}]`,
+			want:     `[{"role":"user","content":"a\nb"}]`,
+		},
+		{
+			name:     "base64 image",
+			messages: `[{"role":"user","content":[{"type":"text","text":"what is this"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}}]}]`,
+			want:     `[{"role":"user","content":[{"type":"text","text":"what is this"},{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBOR"}}]}]`,
+		},
+		{
+			name:     "url image",
+			messages: `[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}}]}]`,
+			want:     `[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]`,
+		},
+		{
+			name:     "pdf document",
+			messages: `[{"role":"user","content":[{"type":"document","title":"spec.pdf","source":{"type":"base64","media_type":"application/pdf","data":"JVBER"}}]}]`,
+			want:     `[{"role":"user","content":[{"type":"file","file":{"filename":"spec.pdf","file_data":"data:application/pdf;base64,JVBER"}}]}]`,
+		},
+		{
+			name:     "text document with title",
+			messages: `[{"role":"user","content":[{"type":"document","title":"notes","source":{"type":"text","media_type":"text/plain","data":"hello"}}]}]`,
+			want:     `[{"role":"user","content":"notes\n\nhello"}]`,
+		},
+		{
+			name: "tool result image moves to a user message",
+			messages: `[{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":[
+				{"type":"text","text":"screenshot taken"},
+				{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"/9j/"}}]}]}]`,
+			want: `[{"role":"tool","tool_call_id":"call_1","content":"screenshot taken"},
+				{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,/9j/"}}]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := `{"model":"m","max_tokens":10,"messages":` + tt.messages + `}`
+			got := convertedField(t, request, OpenAIRequestOptions{}, "messages")
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				t.Errorf("messages:\n got  %s\n want %s", gotJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicToOpenAIRequestUnsupportedMedia(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "file image source", content: `{"type":"image","source":{"type":"file","file_id":"f1"}}`},
+		{name: "base64 image without data", content: `{"type":"image","source":{"type":"base64","media_type":"image/png"}}`},
+		{name: "non-pdf base64 document", content: `{"type":"document","source":{"type":"base64","media_type":"application/msword","data":"AA"}}`},
+		{name: "url document", content: `{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := `{"model":"m","max_tokens":10,"messages":[{"role":"user","content":[` + tt.content + `]}]}`
+			_, err := AnthropicToOpenAIRequest([]byte(request), OpenAIRequestOptions{})
+			if !errors.Is(err, ErrUnsupportedContent) {
+				t.Errorf("error = %v, want ErrUnsupportedContent", err)
+			}
+		})
+	}
+}
+
+func TestAnthropicToOpenAIRequestThinking(t *testing.T) {
+	tests := []struct {
+		name       string
+		thinking   string
+		format     string
+		wantEffort interface{} // reasoning_effort, nil if absent
+		wantBudget interface{} // reasoning.max_tokens, nil if absent
+	}{
+		{name: "no thinking", thinking: `null`},
+		{name: "disabled", thinking: `{"type":"disabled"}`},
+		{name: "small budget", thinking: `{"type":"enabled","budget_tokens":1024}`, wantEffort: "low"},
+		{name: "medium budget", thinking: `{"type":"enabled","budget_tokens":4096}`, wantEffort: "medium"},
+		{name: "large budget", thinking: `{"type":"enabled","budget_tokens":16384}`, wantEffort: "high"},
+		{name: "no budget", thinking: `{"type":"enabled"}`, wantEffort: "medium"},
+		{name: "reasoning format", thinking: `{"type":"enabled","budget_tokens":5000}`, format: "reasoning", wantBudget: float64(5000)},
+		{name: "none format", thinking: `{"type":"enabled","budget_tokens":5000}`, format: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := `{"model":"m","max_tokens":10,"messages":[{"role":"user","content":"hi"}],"thinking":` + tt.thinking + `}`
+			opts := OpenAIRequestOptions{ReasoningFormat: tt.format}
+
+			if got := convertedField(t, request, opts, "reasoning_effort"); got != tt.wantEffort {
+				t.Errorf("reasoning_effort = %v, want %v", got, tt.wantEffort)
+			}
+			var gotBudget interface{}
+			if reasoning, ok := convertedField(t, request, opts, "reasoning").(map[string]interface{}); ok {
+				gotBudget = reasoning["max_tokens"]
+			}
+			if gotBudget != tt.wantBudget {
+				t.Errorf("reasoning.max_tokens = %v, want %v", gotBudget, tt.wantBudget)
+			}
+		})
+	}
+}
+
+func TestAnthropicToOpenAIRequestStreamOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		omit   bool
+		want   interface{} // stream_options, nil if absent
+	}{
+		{name: "not streaming"},
+		{name: "streaming", stream: true, want: map[string]interface{}{"include_usage": true}},
+		{name: "streaming without usage", stream: true, omit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := json.Marshal(map[string]interface{}{
+				"model":      "m",
+				"max_tokens": 10,
+				"stream":     tt.stream,
+				"messages":   []map[string]string{{"role": "user", "content": "hi"}},
+			})
+			got := convertedField(t, string(request), OpenAIRequestOptions{OmitStreamUsage: tt.omit}, "stream_options")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stream_options = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// describeChunks reduces converted OpenAI chunks to one line each: the delta field that
+// was set, a finish_reason or the final usage
+func describeChunks(t *testing.T, chunks []string) []string {
+	t.Helper()
+
+	var lines []string
+	for _, raw := range chunks {
+		if raw == "[DONE]" {
+			lines = append(lines, raw)
+			continue
+		}
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
+			t.Fatalf("invalid chunk %s: %v", raw, err)
+		}
+
+		switch {
+		case chunk.Usage != nil:
+			lines = append(lines, fmt.Sprintf("usage %d+%d", chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens))
+		case len(chunk.Choices) == 0:
+			lines = append(lines, "error")
+		case chunk.Choices[0].FinishReason != nil:
+			lines = append(lines, "finish "+*chunk.Choices[0].FinishReason)
+		default:
+			delta := chunk.Choices[0].Delta
+			switch {
+			case delta.Role != "":
+				lines = append(lines, "role "+delta.Role)
+			case delta.ReasoningContent != "":
+				lines = append(lines, "reasoning "+delta.ReasoningContent)
+			case len(delta.ToolCalls) > 0:
+				call := delta.ToolCalls[0]
+				lines = append(lines, fmt.Sprintf("tool %d %s%s%s", call.Index, call.ID, call.Function.Name, call.Function.Arguments))
+			default:
+				lines = append(lines, fmt.Sprintf("content %v", delta.Content))
+			}
+		}
+	}
+	return lines
+}
+
+func TestAnthropicToOpenAIStreamConverter(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeUsage bool
+		events       []string
+		want         []string
+	}{
+		{
+			name:         "text with usage",
+			includeUsage: true,
+			events: []string{
+				`{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":10,"cache_read_input_tokens":5}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"output_tokens":3}}`,
+				`{"type":"message_stop"}`,
+			},
+			want: []string{"role assistant", "content Hi", "finish length", "usage 15+3", "[DONE]"},
+		},
+		{
+			name: "thinking and tool calls",
+			events: []string{
+				`{"type":"message_start","message":{"id":"msg_1","usage":{}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`,
+				`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_a","name":"read"}}`,
+				`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+				`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_b","name":"grep"}}`,
+				`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"q\":1}"}}`,
+				`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`,
+				`{"type":"message_stop"}`,
+			},
+			want: []string{
+				"role assistant",
+				"reasoning hmm",
+				"tool 0 toolu_aread",
+				"tool 0 {}",
+				"tool 1 toolu_bgrep",
+				`tool 1 {"q":1}`,
+				"finish tool_calls",
+				"[DONE]",
+			},
+		},
+		{
+			name: "error ends the stream",
+			events: []string{
+				`{"type":"message_start","message":{"id":"msg_1","usage":{}}}`,
+				`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+				`{"type":"message_stop"}`,
+			},
+			want: []string{"role assistant", "error", "[DONE]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := NewAnthropicToOpenAIStreamConverter("model", tt.includeUsage)
+			var chunks []string
+			for _, event := range tt.events {
+				converted, err := converter.Convert([]byte(event))
+				if err != nil {
+					t.Fatalf("Convert(%s): %v", event, err)
+				}
+				chunks = append(chunks, converted...)
+			}
+			if got := describeChunks(t, chunks); !slices.Equal(got, tt.want) {
+				t.Errorf("chunks:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AnthropicToResponsesRequest converts an Anthropic request to the OpenAI Responses API format
+func AnthropicToResponsesRequest(anthropicBody []byte, opts OpenAIRequestOptions) ([]byte, error) {
+	var anthropicReq AnthropicRequest
+	if err := json.Unmarshal(anthropicBody, &anthropicReq); err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic request: %w", err)
+	}
+
+	// Convert messages to input items
+	var input []interface{}
+	for _, msg := range anthropicReq.Messages {
+		items, err := convertMessageToResponsesItems(msg)
+		if err != nil {
+			return nil, err
+		}
+		input = append(input, items...)
+	}
+
+	// Build Responses request
+	responsesReq := ResponsesRequest{
+		Model:           anthropicReq.Model,
+		Input:           input,
+		Instructions:    extractSystemText(anthropicReq.System),
+		MaxOutputTokens: anthropicReq.MaxTokens,
+		Temperature:     anthropicReq.Temperature,
+		TopP:            anthropicReq.TopP,
+		Stream:          anthropicReq.Stream,
+	}
+
+	// Convert tools; Responses tools are flat, unlike chat completions
+	if len(anthropicReq.Tools) > 0 {
+		responsesReq.Tools = convertToolsToResponses(anthropicReq.Tools)
+	}
+
+	// Convert tool_choice if present
+	if anthropicReq.ToolChoice != nil {
+		responsesReq.ToolChoice = convertToolChoiceToResponses(anthropicReq.ToolChoice)
+	}
+
+	// Convert extended thinking to a reasoning effort, asking for summaries so thinking can be streamed back
+	if budget, ok := thinkingBudget(anthropicReq.Thinking); ok && opts.ReasoningFormat != "none" {
+		responsesReq.Reasoning = map[string]interface{}{
+			"effort":  reasoningEffortForBudget(budget),
+			"summary": "auto",
+		}
+	}
+
+	// Serialize to JSON
+	responsesBody, err := json.Marshal(responsesReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Responses request: %w", err)
+	}
+
+	return responsesBody, nil
+}
+
+// convertMessageToResponsesItems converts one Anthropic message into Responses input items.
+// Text and media become message items, tool_use blocks become function_call items and
+// tool_result blocks become function_call_output items, keeping their original order.
+func convertMessageToResponsesItems(msg AnthropicMessage) ([]interface{}, error) {
+	textType := "input_text"
+	if msg.Role == "assistant" {
+		textType = "output_text"
+	}
+
+	if text, ok := msg.Content.(string); ok {
+		return []interface{}{responsesMessageItem(msg.Role, []interface{}{
+			map[string]interface{}{"type": textType, "text": text},
+		})}, nil
+	}
+
+	var items []interface{}
+	var parts []interface{}
+	flush := func() {
+		if len(parts) > 0 {
+			items = append(items, responsesMessageItem(msg.Role, parts))
+			parts = nil
+		}
+	}
+
+	for _, block := range contentBlocks(msg.Content) {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		blockType, _ := blockMap["type"].(string)
+		switch blockType {
+		case "tool_use":
+			flush()
+			argsBytes, _ := json.Marshal(blockMap["input"])
+			items = append(items, map[string]interface{}{
+				"type":      "function_call",
+				"call_id":   blockMap["id"],
+				"name":      blockMap["name"],
+				"arguments": string(argsBytes),
+			})
+
+		case "tool_result":
+			flush()
+			toolUseID, _ := blockMap["tool_use_id"].(string)
+
+			// Function call outputs only carry text, so images move to a following user message
+			output, imageParts, err := convertToolResultContent(blockMap["content"])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, map[string]interface{}{
+				"type":    "function_call_output",
+				"call_id": toolUseID,
+				"output":  output,
+			})
+			for _, part := range imageParts {
+				parts = append(parts, chatPartToResponses(part.(map[string]interface{}), textType))
+			}
+
+		default:
+			chatParts, err := convertContentBlockToOpenAI(blockMap)
+			if err != nil {
+				return nil, err
+			}
+			for _, part := range chatParts {
+				parts = append(parts, chatPartToResponses(part.(map[string]interface{}), textType))
+			}
+		}
+	}
+	flush()
+
+	return items, nil
+}
+
+// responsesMessageItem builds a Responses message input item
+func responsesMessageItem(role string, content []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "message",
+		"role":    role,
+		"content": content,
+	}
+}
+
+// chatPartToResponses maps a chat completions content part to its Responses equivalent
+func chatPartToResponses(part map[string]interface{}, textType string) map[string]interface{} {
+	switch part["type"] {
+	case "image_url":
+		imageURL, _ := part["image_url"].(map[string]interface{})
+		return map[string]interface{}{
+			"type":      "input_image",
+			"image_url": imageURL["url"],
+		}
+	case "file":
+		file, _ := part["file"].(map[string]interface{})
+		return map[string]interface{}{
+			"type":      "input_file",
+			"filename":  file["filename"],
+			"file_data": file["file_data"],
+		}
+	default:
+		return map[string]interface{}{
+			"type": textType,
+			"text": part["text"],
+		}
+	}
+}
+
+// convertToolsToResponses converts Anthropic tools to Responses function tools
+// Anthropic: {"name": "...", "description": "...", "input_schema": {...}}
+// Responses: {"type": "function", "name": "...", "description": "...", "parameters": {...}}
+func convertToolsToResponses(anthropicTools []interface{}) []interface{} {
+	responsesTools := make([]interface{}, 0, len(anthropicTools))
+	for _, tool := range convertToolsToOpenAI(anthropicTools) {
+		function := tool.(map[string]interface{})["function"].(map[string]interface{})
+		function["type"] = "function"
+		responsesTools = append(responsesTools, function)
+	}
+	return responsesTools
+}
+
+// convertToolChoiceToResponses converts Anthropic tool_choice to Responses format
+func convertToolChoiceToResponses(anthropicToolChoice interface{}) interface{} {
+	toolChoice := convertToolChoiceToOpenAI(anthropicToolChoice)
+
+	// A specific function is {"type": "function", "name": "..."} without the nested object
+	if toolChoiceMap, ok := toolChoice.(map[string]interface{}); ok {
+		if function, ok := toolChoiceMap["function"].(map[string]interface{}); ok {
+			return map[string]interface{}{
+				"type": "function",
+				"name": function["name"],
+			}
+		}
+		if choiceType, _ := toolChoiceMap["type"].(string); choiceType == "none" {
+			return "none"
+		}
+	}
+	return toolChoice
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAnthropicToResponsesRequestInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages string
+		want     string // Expected Responses input items
+	}{
+		{
+			name:     "string content",
+			messages: `[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]`,
+			want: `[{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hello"}]}]`,
+		},
+		{
+			name: "tool use splits the message",
+			messages: `[{"role":"assistant","content":[
+				{"type":"text","text":"reading"},
+				{"type":"tool_use","id":"toolu_1","name":"read","input":{"path":"a"}},
+				{"type":"text","text":"done"}]}]`,
+			want: `[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"reading"}]},
+				{"type":"function_call","call_id":"toolu_1","name":"read","arguments":"{\"path\":\"a\"}"},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"done"}]}]`,
+		},
+		{
+			name: "tool result with image",
+			messages: `[{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[
+				{"type":"text","text":"screenshot"},
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}}]}]}]`,
+			want: `[{"type":"function_call_output","call_id":"toolu_1","output":"screenshot"},
+				{"type":"message","role":"user","content":[{"type":"input_image","image_url":"data:image/png;base64,iVBOR"}]}]`,
+		},
+		{
+			name:     "pdf document",
+			messages: `[{"role":"user","content":[{"type":"document","title":"spec.pdf","source":{"type":"base64","media_type":"application/pdf","data":"JVBER"}}]}]`,
+			want: `[{"type":"message","role":"user","content":[
+				{"type":"input_file","filename":"spec.pdf","file_data":"data:application/pdf;base64,JVBER"}]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := `{"model":"m","max_tokens":10,"messages":` + tt.messages + `}`
+			body, err := AnthropicToResponsesRequest([]byte(request), OpenAIRequestOptions{})
+			if err != nil {
+				t.Fatalf("AnthropicToResponsesRequest: %v", err)
+			}
+			var converted map[string]interface{}
+			if err := json.Unmarshal(body, &converted); err != nil {
+				t.Fatalf("invalid Responses request %s: %v", body, err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(converted["input"], want) {
+				gotJSON, _ := json.Marshal(converted["input"])
+				t.Errorf("input:\n got  %s\n want %s", gotJSON, tt.want)
+			}
+		})
+	}
+}
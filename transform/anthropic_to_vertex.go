@@ -0,0 +1,41 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// VertexAnthropicVersion is the API version Vertex AI expects in the request body
+const VertexAnthropicVersion = "vertex-2023-10-16"
+
+// AnthropicToVertexRequest prepares an Anthropic request for the Vertex AI rawPredict API.
+// The model moves into the URL, so the request path is returned too. Token counting keeps
+// the model in the body and uses the shared count-tokens endpoint.
+func AnthropicToVertexRequest(anthropicBody []byte, projectID, region string, countTokens bool) ([]byte, string, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(anthropicBody, &request); err != nil {
+		return nil, "", fmt.Errorf("failed to parse Anthropic request: %w", err)
+	}
+
+	model, _ := request["model"].(string)
+	stream, _ := request["stream"].(bool)
+	if !countTokens {
+		delete(request, "model")
+	}
+	request["anthropic_version"] = VertexAnthropicVersion
+
+	vertexBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal Vertex request: %w", err)
+	}
+
+	modelsPath := "/v1/projects/" + projectID + "/locations/" + region + "/publishers/anthropic/models/"
+	switch {
+	case countTokens:
+		return vertexBody, modelsPath + "count-tokens:rawPredict", nil
+	case stream:
+		return vertexBody, modelsPath + model + ":streamRawPredict", nil
+	default:
+		return vertexBody, modelsPath + model + ":rawPredict", nil
+	}
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAnthropicToVertexRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     string
+		countTokens bool
+		wantPath    string
+		wantModel   interface{} // model in the body, nil if absent
+	}{
+		{
+			name:     "message",
+			request:  `{"model":"claude-sonnet-4@20250514","max_tokens":10}`,
+			wantPath: "/v1/projects/p/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:rawPredict",
+		},
+		{
+			name:     "streaming message",
+			request:  `{"model":"claude-sonnet-4@20250514","max_tokens":10,"stream":true}`,
+			wantPath: "/v1/projects/p/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:streamRawPredict",
+		},
+		{
+			name:        "count tokens keeps the model",
+			request:     `{"model":"claude-sonnet-4@20250514","messages":[]}`,
+			countTokens: true,
+			wantPath:    "/v1/projects/p/locations/us-east5/publishers/anthropic/models/count-tokens:rawPredict",
+			wantModel:   "claude-sonnet-4@20250514",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, path, err := AnthropicToVertexRequest([]byte(tt.request), "p", "us-east5", tt.countTokens)
+			if err != nil {
+				t.Fatalf("AnthropicToVertexRequest: %v", err)
+			}
+			if path != tt.wantPath {
+				t.Errorf("path = %s, want %s", path, tt.wantPath)
+			}
+
+			var converted map[string]interface{}
+			if err := json.Unmarshal(body, &converted); err != nil {
+				t.Fatalf("invalid Vertex request %s: %v", body, err)
+			}
+			if converted["anthropic_version"] != VertexAnthropicVersion {
+				t.Errorf("anthropic_version = %v, want %s", converted["anthropic_version"], VertexAnthropicVersion)
+			}
+			if converted["model"] != tt.wantModel {
+				t.Errorf("model = %v, want %v", converted["model"], tt.wantModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// bedrockMaxMessageSize bounds a single event-stream message, matching the AWS limit
+const bedrockMaxMessageSize = 16 * 1024 * 1024
+
+// BedrockEventStreamReader decodes the binary AWS event-stream framing returned by
+// InvokeModelWithResponseStream into Anthropic SSE text. Each chunk message carries
+// a base64 encoded Anthropic stream event, so the output can be handled exactly
+// like a native Anthropic stream.
+type BedrockEventStreamReader struct {
+	body    io.ReadCloser
+	pending bytes.Buffer
+	done    bool
+}
+
+// NewBedrockEventStreamReader wraps a Bedrock streaming response body
+func NewBedrockEventStreamReader(body io.ReadCloser) *BedrockEventStreamReader {
+	return &BedrockEventStreamReader{body: body}
+}
+
+// Read returns decoded SSE text, decoding upstream messages as needed
+func (r *BedrockEventStreamReader) Read(p []byte) (int, error) {
+	for r.pending.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.decodeNext(); err != nil {
+			if err != io.EOF {
+				// Surface framing errors to the client as an Anthropic error event
+				r.writeEvent("error", []byte(marshalAnthropicError("api_error", err.Error())))
+			}
+			r.done = true
+		}
+	}
+	return r.pending.Read(p)
+}
+
+// Close closes the upstream body
+func (r *BedrockEventStreamReader) Close() error {
+	return r.body.Close()
+}
+
+// decodeNext reads one event-stream message and appends its SSE form to pending
+func (r *BedrockEventStreamReader) decodeNext() error {
+	// Prelude: total length, headers length and a CRC of those 8 bytes
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r.body, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.New("bedrock event stream ended mid-message")
+		}
+		return err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return errors.New("bedrock event stream prelude checksum mismatch")
+	}
+	if totalLength < 16+headersLength || totalLength > bedrockMaxMessageSize {
+		return fmt.Errorf("bedrock event stream message has invalid length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude)
+	if _, err := io.ReadFull(r.body, message[12:]); err != nil {
+		return errors.New("bedrock event stream ended mid-message")
+	}
+	if crc32.ChecksumIEEE(message[:totalLength-4]) != binary.BigEndian.Uint32(message[totalLength-4:]) {
+		return errors.New("bedrock event stream message checksum mismatch")
+	}
+
+	headers, err := parseEventStreamHeaders(message[12 : 12+headersLength])
+	if err != nil {
+		return err
+	}
+	payload := message[12+headersLength : totalLength-4]
+
+	switch headers[":message-type"] {
+	case "event":
+		if headers[":event-type"] != "chunk" {
+			return nil
+		}
+		var chunk struct {
+			Bytes string `json:"bytes"`
+		}
+		if err := json.Unmarshal(payload, &chunk); err != nil {
+			return fmt.Errorf("failed to parse bedrock stream chunk: %w", err)
+		}
+		event, err := base64.StdEncoding.DecodeString(chunk.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to decode bedrock stream chunk: %w", err)
+		}
+
+		var eventType struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(event, &eventType)
+		r.writeEvent(eventType.Type, event)
+
+	case "exception", "error":
+		var exception struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(payload, &exception)
+		exceptionType := headers[":exception-type"]
+		if exceptionType == "" {
+			exceptionType = headers[":error-code"]
+		}
+		r.writeEvent("error", []byte(marshalAnthropicError(bedrockErrorType(exceptionType), exceptionType+": "+exception.Message)))
+	}
+
+	return nil
+}
+
+// writeEvent appends one SSE event to the pending output
+func (r *BedrockEventStreamReader) writeEvent(eventType string, data []byte) {
+	if eventType != "" {
+		r.pending.WriteString("event: " + eventType + "\n")
+	}
+	r.pending.WriteString("data: ")
+	r.pending.Write(data)
+	r.pending.WriteString("\n\n")
+}
+
+// parseEventStreamHeaders decodes event-stream headers, keeping the string values
+func parseEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	errTruncated := errors.New("bedrock event stream headers are truncated")
+
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 2+nameLength {
+			return nil, errTruncated
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+
+		// Value sizes by type: bool true/false, byte, short, int, long, bytes, string, timestamp, uuid
+		var size int
+		switch valueType {
+		case 0, 1:
+			size = 0
+		case 2:
+			size = 1
+		case 3:
+			size = 2
+		case 4:
+			size = 4
+		case 5, 8:
+			size = 8
+		case 9:
+			size = 16
+		case 6, 7:
+			if len(data) < 2 {
+				return nil, errTruncated
+			}
+			size = int(binary.BigEndian.Uint16(data[0:2]))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("bedrock event stream header %s has unknown type %d", name, valueType)
+		}
+
+		if len(data) < size {
+			return nil, errTruncated
+		}
+		if valueType == 7 {
+			headers[name] = string(data[:size])
+		}
+		data = data[size:]
+	}
+
+	return headers, nil
+}
+
+// bedrockErrorType maps a Bedrock exception to an Anthropic error type
+func bedrockErrorType(exceptionType string) string {
+	switch exceptionType {
+	case "throttlingException", "serviceQuotaExceededException":
+		return "rate_limit_error"
+	case "validationException":
+		return "invalid_request_error"
+	case "modelTimeoutException", "serviceUnavailableException":
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
@@ -0,0 +1,139 @@
+package transform
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+// eventStreamMessage encodes one AWS event-stream message with string headers
+func eventStreamMessage(headers map[string]string, payload string) []byte {
+	var headerBytes []byte
+	for name, value := range headers {
+		headerBytes = append(headerBytes, byte(len(name)))
+		headerBytes = append(headerBytes, name...)
+		headerBytes = append(headerBytes, 7)
+		headerBytes = binary.BigEndian.AppendUint16(headerBytes, uint16(len(value)))
+		headerBytes = append(headerBytes, value...)
+	}
+
+	totalLength := 16 + len(headerBytes) + len(payload)
+	message := binary.BigEndian.AppendUint32(nil, uint32(totalLength))
+	message = binary.BigEndian.AppendUint32(message, uint32(len(headerBytes)))
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, headerBytes...)
+	message = append(message, payload...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+// bedrockChunk encodes an Anthropic stream event as a Bedrock chunk message
+func bedrockChunk(event string) []byte {
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	return eventStreamMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   "chunk",
+	}, payload)
+}
+
+func TestBedrockEventStreamReader(t *testing.T) {
+	corrupted := bedrockChunk(`{"type":"message_stop"}`)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		upstream [][]byte
+		want     string
+	}{
+		{
+			name: "chunks",
+			upstream: [][]byte{
+				bedrockChunk(`{"type":"message_start"}`),
+				eventStreamMessage(map[string]string{":message-type": "event", ":event-type": "metadata"}, `{}`),
+				bedrockChunk(`{"type":"message_stop"}`),
+			},
+			want: "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+		},
+		{
+			name: "exception",
+			upstream: [][]byte{
+				eventStreamMessage(map[string]string{
+					":message-type":   "exception",
+					":exception-type": "throttlingException",
+				}, `{"message":"Too many requests"}`),
+			},
+			want: "event: error\ndata: " + marshalAnthropicError("rate_limit_error", "throttlingException: Too many requests") + "\n\n",
+		},
+		{
+			name:     "checksum mismatch",
+			upstream: [][]byte{corrupted},
+			want:     "event: error\ndata: " + marshalAnthropicError("api_error", "bedrock event stream message checksum mismatch") + "\n\n",
+		},
+		{
+			name:     "cut off mid-message",
+			upstream: [][]byte{bedrockChunk(`{"type":"message_start"}`)[:20]},
+			want:     "event: error\ndata: " + marshalAnthropicError("api_error", "bedrock event stream ended mid-message") + "\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstream []byte
+			for _, message := range tt.upstream {
+				upstream = append(upstream, message...)
+			}
+			reader := NewBedrockEventStreamReader(io.NopCloser(strings.NewReader(string(upstream))))
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("output:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEventStreamHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "string and skipped values",
+			// ":a" = "x" (string), "b" = true, "c" = int 7, ":d" = "yz" (string)
+			data: []byte{
+				2, ':', 'a', 7, 0, 1, 'x',
+				1, 'b', 0,
+				1, 'c', 4, 0, 0, 0, 7,
+				2, ':', 'd', 7, 0, 2, 'y', 'z',
+			},
+			want: map[string]string{":a": "x", ":d": "yz"},
+		},
+		{name: "truncated name", data: []byte{5, 'a'}, wantErr: true},
+		{name: "truncated value", data: []byte{1, 'a', 7, 0, 4, 'x'}, wantErr: true},
+		{name: "unknown type", data: []byte{1, 'a', 42}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEventStreamHeaders(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("headers = %v, want %v", got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("%s = %q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
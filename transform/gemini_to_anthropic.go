@@ -0,0 +1,203 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GeminiToAnthropicResponse converts a Gemini generateContent response to Anthropic format
+func GeminiToAnthropicResponse(geminiBody []byte, model string) ([]byte, error) {
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(geminiBody, &geminiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini response: %w", err)
+	}
+
+	id := geminiResp.ResponseID
+	if id == "" {
+		id = GenerateAnthropicMessageID()
+	}
+
+	anthropicResp := AnthropicResponse{
+		ID:         id,
+		Type:       "message",
+		Role:       "assistant",
+		Model:      model,
+		Content:    []AnthropicContentBlock{},
+		StopReason: "end_turn",
+		Usage:      geminiUsageToAnthropic(geminiResp.UsageMetadata),
+	}
+
+	// Convert the first candidate's parts to content blocks
+	if len(geminiResp.Candidates) > 0 {
+		candidate := geminiResp.Candidates[0]
+		hasToolCall := false
+
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				hasToolCall = true
+				anthropicResp.Content = append(anthropicResp.Content, geminiToolUseBlock(part.FunctionCall))
+			case part.Thought && part.Text != "":
+				anthropicResp.Content = append(anthropicResp.Content, AnthropicContentBlock{
+					Type:     "thinking",
+					Thinking: part.Text,
+				})
+			case part.Text != "":
+				anthropicResp.Content = append(anthropicResp.Content, AnthropicContentBlock{
+					Type: "text",
+					Text: part.Text,
+				})
+			}
+		}
+
+		anthropicResp.StopReason = mapGeminiFinishReason(candidate.FinishReason, hasToolCall)
+	}
+
+	// Serialize to JSON
+	anthropicBody, err := json.Marshal(anthropicResp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Anthropic response: %w", err)
+	}
+
+	return anthropicBody, nil
+}
+
+// GeminiStreamConverter converts a Gemini streamGenerateContent SSE stream into
+// Anthropic SSE events. Every chunk is a partial response whose parts extend
+// the current thought or text block; function calls arrive whole and become
+// complete tool_use blocks.
+type GeminiStreamConverter struct {
+	anthropicStream
+	usage       *GeminiUsageMetadata
+	hasToolCall bool
+}
+
+// NewGeminiStreamConverter creates a converter for a single Gemini stream
+func NewGeminiStreamConverter(model string) *GeminiStreamConverter {
+	return &GeminiStreamConverter{
+		anthropicStream: newAnthropicStream(model),
+	}
+}
+
+// Convert converts one Gemini stream chunk into zero or more Anthropic events
+func (s *GeminiStreamConverter) Convert(geminiChunk []byte) ([]string, error) {
+	var chunk GeminiResponse
+	if err := json.Unmarshal(geminiChunk, &chunk); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini stream chunk: %w", err)
+	}
+
+	var events []string
+
+	if s.finished {
+		return events, nil
+	}
+
+	if !s.started {
+		events = append(events, s.start(chunk.ResponseID, AnthropicUsage{})...)
+	}
+
+	if chunk.UsageMetadata != nil {
+		s.usage = chunk.UsageMetadata
+	}
+
+	if len(chunk.Candidates) == 0 {
+		return events, nil
+	}
+	candidate := chunk.Candidates[0]
+
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			s.hasToolCall = true
+			block := geminiToolUseBlock(part.FunctionCall)
+			argsBytes, _ := json.Marshal(block.Input)
+			block.Input = map[string]interface{}{}
+
+			events = append(events, s.openBlock(&block)...)
+			events = append(events, s.blockDelta(&AnthropicDelta{
+				Type:        "input_json_delta",
+				PartialJSON: string(argsBytes),
+			}))
+			events = append(events, s.closeOpenBlock()...)
+
+		case part.Thought && part.Text != "":
+			if s.openType != "thinking" {
+				events = append(events, s.openBlock(&AnthropicContentBlock{Type: "thinking"})...)
+			}
+			events = append(events, s.blockDelta(&AnthropicDelta{
+				Type:     "thinking_delta",
+				Thinking: part.Text,
+			}))
+
+		case part.Text != "":
+			if s.openType != "text" {
+				events = append(events, s.openBlock(&AnthropicContentBlock{Type: "text"})...)
+			}
+			events = append(events, s.blockDelta(&AnthropicDelta{
+				Type: "text_delta",
+				Text: part.Text,
+			}))
+		}
+	}
+
+	// The chunk with the finish reason also carries the final usage
+	if candidate.FinishReason != "" {
+		events = append(events, s.finish(mapGeminiFinishReason(candidate.FinishReason, s.hasToolCall), geminiUsageToAnthropic(s.usage))...)
+	}
+
+	return events, nil
+}
+
+// Finish adds nothing: the chunk with the finish reason ends the message, and a
+// stream that stops before it was cut off
+func (s *GeminiStreamConverter) Finish() []string {
+	return nil
+}
+
+// geminiToolUseBlock converts a Gemini function call to a tool_use block
+func geminiToolUseBlock(call *GeminiFunctionCall) AnthropicContentBlock {
+	id := call.ID
+	if id == "" {
+		id = fmt.Sprintf("toolu_%d", time.Now().UnixNano())
+	}
+
+	input := call.Args
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	return AnthropicContentBlock{
+		Type:  "tool_use",
+		ID:    id,
+		Name:  call.Name,
+		Input: input,
+	}
+}
+
+// mapGeminiFinishReason maps a Gemini finishReason to an Anthropic stop_reason
+func mapGeminiFinishReason(finishReason string, hasToolCall bool) string {
+	switch finishReason {
+	case "MAX_TOKENS":
+		return "max_tokens"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return "stop_sequence"
+	}
+	if hasToolCall {
+		return "tool_use"
+	}
+	return "end_turn"
+}
+
+// geminiUsageToAnthropic converts Gemini usage metadata; thought tokens count as output
+func geminiUsageToAnthropic(usage *GeminiUsageMetadata) AnthropicUsage {
+	if usage == nil {
+		return AnthropicUsage{}
+	}
+	return AnthropicUsage{
+		InputTokens:  usage.PromptTokenCount - usage.CachedContentTokenCount,
+		OutputTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+
+		CacheReadInputTokens: usage.CachedContentTokenCount,
+	}
+}
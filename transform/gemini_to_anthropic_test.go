@@ -0,0 +1,89 @@
+package transform
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGeminiStreamConverter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name: "thought then text",
+			chunks: []string{
+				`{"responseId":"r1","candidates":[{"content":{"parts":[{"text":"plan","thought":true}]}}]}`,
+				`{"candidates":[{"content":{"parts":[{"text":"Hel"}]}}]}`,
+				`{"candidates":[{"content":{"parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":2}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 thinking",
+				"content_block_delta 0 plan",
+				"content_block_stop 0",
+				"content_block_start 1 text",
+				"content_block_delta 1 Hel",
+				"content_block_delta 1 lo",
+				"content_block_stop 1",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "function calls arrive whole",
+			chunks: []string{
+				`{"candidates":[{"content":{"parts":[{"text":"checking"},{"functionCall":{"id":"call_a","name":"read","args":{"path":"a"}}},{"functionCall":{"id":"call_b","name":"list"}}]},"finishReason":"STOP"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 checking",
+				"content_block_stop 0",
+				"content_block_start 1 tool_use call_a read",
+				`content_block_delta 1 {"path":"a"}`,
+				"content_block_stop 1",
+				"content_block_start 2 tool_use call_b list",
+				"content_block_delta 2 {}",
+				"content_block_stop 2",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "max tokens",
+			chunks: []string{
+				`{"candidates":[{"content":{"parts":[{"text":"cut"}]},"finishReason":"MAX_TOKENS"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 cut",
+				"content_block_stop 0",
+				"message_delta max_tokens",
+				"message_stop",
+			},
+		},
+		{
+			name: "cut off before the finish reason",
+			chunks: []string{
+				`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 hi",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertAll(t, NewGeminiStreamConverter("model"), tt.chunks)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
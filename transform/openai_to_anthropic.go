@@ -30,11 +30,15 @@ func OpenAIToAnthropicResponse(openaiBody []byte, model string) ([]byte, error)
 	if len(openaiResp.Choices) > 0 {
 		choice := openaiResp.Choices[0]
 
-		// Convert content and tool_calls to content blocks
-		contentBlocks := openAIContentToTextBlocks(choice.Message.Content)
-		if contentBlocks == nil {
-			contentBlocks = []AnthropicContentBlock{}
+		// Convert reasoning, content and tool_calls to content blocks
+		contentBlocks := []AnthropicContentBlock{}
+		if reasoning := firstNonEmpty(choice.Message.ReasoningContent, choice.Message.Reasoning); reasoning != "" {
+			contentBlocks = append(contentBlocks, AnthropicContentBlock{
+				Type:     "thinking",
+				Thinking: reasoning,
+			})
 		}
+		contentBlocks = append(contentBlocks, openAIContentToTextBlocks(choice.Message.Content)...)
 
 		// Convert tool_calls to tool_use blocks
 		if len(choice.Message.ToolCalls) > 0 {
@@ -68,18 +72,7 @@ func OpenAIToAnthropicResponse(openaiBody []byte, model string) ([]byte, error)
 		anthropicResp.Content = contentBlocks
 
 		// Map finish reason
-		switch choice.FinishReason {
-		case "stop":
-			anthropicResp.StopReason = "end_turn"
-		case "length":
-			anthropicResp.StopReason = "max_tokens"
-		case "content_filter":
-			anthropicResp.StopReason = "stop_sequence"
-		case "tool_calls":
-			anthropicResp.StopReason = "tool_use"
-		default:
-			anthropicResp.StopReason = "end_turn"
-		}
+		anthropicResp.StopReason = mapOpenAIFinishReason(choice.FinishReason)
 	}
 
 	// Serialize to JSON
@@ -91,8 +84,43 @@ func OpenAIToAnthropicResponse(openaiBody []byte, model string) ([]byte, error)
 	return anthropicBody, nil
 }
 
-// OpenAIStreamToAnthropicStream converts an OpenAI stream chunk to Anthropic SSE format
-func OpenAIStreamToAnthropicStream(openaiChunk []byte, model string) ([]string, error) {
+// OpenAIStreamConverter converts an OpenAI chat completion stream into Anthropic
+// SSE events. OpenAI spreads text and tool calls over many chunks, so the
+// converter keeps track of the open content block between chunks and assigns
+// Anthropic block indices in the order blocks appear.
+//
+// Parallel tool calls may interleave their argument fragments, while an
+// Anthropic block must be complete before the next one opens, so tool calls
+// are collected and sent as whole tool_use blocks once finish_reason arrives.
+//
+// With stream_options.include_usage, OpenAI sends token usage in a separate
+// chunk after the finish_reason, so message_delta and message_stop are held
+// back until that chunk arrives or the stream ends.
+type OpenAIStreamConverter struct {
+	anthropicStream
+	stopReason string                  // Set once finish_reason is seen
+	usage      *OpenAIUsage            // Latest usage reported by the upstream
+	toolCalls  []*streamToolCall       // Tool calls in the order they started
+	toolIndex  map[int]*streamToolCall // Latest tool call per OpenAI tool_call index
+}
+
+// streamToolCall collects the fragments of one streamed tool call
+type streamToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// NewOpenAIStreamConverter creates a converter for a single OpenAI stream
+func NewOpenAIStreamConverter(model string) *OpenAIStreamConverter {
+	return &OpenAIStreamConverter{
+		anthropicStream: newAnthropicStream(model),
+		toolIndex:       make(map[int]*streamToolCall),
+	}
+}
+
+// Convert converts one OpenAI stream chunk into zero or more Anthropic events
+func (s *OpenAIStreamConverter) Convert(openaiChunk []byte) ([]string, error) {
 	var chunk OpenAIStreamChunk
 	if err := json.Unmarshal(openaiChunk, &chunk); err != nil {
 		return nil, fmt.Errorf("failed to parse OpenAI stream chunk: %w", err)
@@ -100,102 +128,150 @@ func OpenAIStreamToAnthropicStream(openaiChunk []byte, model string) ([]string,
 
 	var events []string
 
+	if s.finished {
+		return events, nil
+	}
+
+	if chunk.Usage != nil {
+		s.usage = chunk.Usage
+	}
+
+	if !s.started {
+		// Prompt tokens are only known here if the upstream reports usage on every chunk
+		events = append(events, s.start(chunk.ID, s.anthropicUsage())...)
+	}
+
+	// Usage chunk after finish_reason completes the message
+	if s.stopReason != "" {
+		if s.usage != nil {
+			events = append(events, s.finish(s.stopReason, s.anthropicUsage())...)
+		}
+		return events, nil
+	}
+
 	if len(chunk.Choices) == 0 {
 		return events, nil
 	}
 
 	choice := chunk.Choices[0]
 
-	// Handle first chunk with role
-	if choice.Delta.Role != "" {
-		// Send message_start event
-		messageStart := AnthropicStreamEvent{
-			Type: "message_start",
-			Message: &AnthropicResponse{
-				ID:    chunk.ID,
-				Type:  "message",
-				Role:  "assistant",
-				Model: model,
-				Usage: AnthropicUsage{
-					InputTokens:  0,
-					OutputTokens: 0,
-				},
-			},
-		}
-		data, _ := json.Marshal(messageStart)
-		events = append(events, string(data))
-
-		// Send content_block_start
-		contentBlockStart := AnthropicStreamEvent{
-			Type:  "content_block_start",
-			Index: 0,
-			ContentBlock: &AnthropicContentBlock{
-				Type: "text",
-				Text: "",
-			},
-		}
-		data, _ = json.Marshal(contentBlockStart)
-		events = append(events, string(data))
-	}
-
-	// Handle content delta
+	// Handle reasoning delta
+	if reasoningDelta := firstNonEmpty(choice.Delta.ReasoningContent, choice.Delta.Reasoning); reasoningDelta != "" {
+		if s.openType != "thinking" {
+			events = append(events, s.openBlock(&AnthropicContentBlock{Type: "thinking"})...)
+		}
+		events = append(events, s.blockDelta(&AnthropicDelta{
+			Type:     "thinking_delta",
+			Thinking: reasoningDelta,
+		}))
+	}
+
+	// Handle text delta
 	textDelta := joinTextSegments(ExtractOpenAIText(choice.Delta.Content))
 	if textDelta != "" {
-		contentDelta := AnthropicStreamEvent{
-			Type:  "content_block_delta",
-			Index: 0,
-			Delta: &AnthropicDelta{
-				Type: "text_delta",
-				Text: textDelta,
-			},
+		if s.openType != "text" {
+			events = append(events, s.openBlock(&AnthropicContentBlock{Type: "text", Text: ""})...)
 		}
-		data, _ := json.Marshal(contentDelta)
-		events = append(events, string(data))
+		events = append(events, s.blockDelta(&AnthropicDelta{
+			Type: "text_delta",
+			Text: textDelta,
+		}))
 	}
 
-	// Handle finish reason
-	if choice.FinishReason != nil && *choice.FinishReason != "" {
-		// Send content_block_stop
-		contentBlockStop := AnthropicStreamEvent{
-			Type:  "content_block_stop",
-			Index: 0,
+	// Collect tool call fragments
+	for _, toolCall := range choice.Delta.ToolCalls {
+		call, exists := s.toolIndex[toolCall.Index]
+		if !exists || (toolCall.ID != "" && toolCall.ID != call.id) {
+			id := toolCall.ID
+			if id == "" {
+				id = fmt.Sprintf("toolu_%d", time.Now().UnixNano())
+			}
+			call = &streamToolCall{id: id}
+			s.toolIndex[toolCall.Index] = call
+			s.toolCalls = append(s.toolCalls, call)
 		}
-		data, _ := json.Marshal(contentBlockStop)
-		events = append(events, string(data))
-
-		// Map finish reason
-		stopReason := "end_turn"
-		switch *choice.FinishReason {
-		case "stop":
-			stopReason = "end_turn"
-		case "length":
-			stopReason = "max_tokens"
-		case "content_filter":
-			stopReason = "stop_sequence"
+		if call.name == "" {
+			call.name = toolCall.Function.Name
 		}
+		call.arguments.WriteString(toolCall.Function.Arguments)
+	}
 
-		// Send message_delta with stop reason
-		messageDelta := AnthropicStreamEvent{
-			Type: "message_delta",
-			Delta: &AnthropicDelta{
-				StopReason: stopReason,
-			},
-			Usage: &AnthropicUsage{
-				OutputTokens: 0, // OpenAI doesn't provide token counts in stream
-			},
+	// Handle finish reason; the message is completed once usage is known
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		events = append(events, s.closeOpenBlock()...)
+		events = append(events, s.flushToolCalls()...)
+		s.stopReason = mapOpenAIFinishReason(*choice.FinishReason)
+		if s.usage != nil {
+			events = append(events, s.finish(s.stopReason, s.anthropicUsage())...)
 		}
-		data, _ = json.Marshal(messageDelta)
-		events = append(events, string(data))
+	}
+
+	return events, nil
+}
+
+// Finish completes the message when the upstream stream ends after finish_reason
+// without a usage chunk. A stream without finish_reason was cut off and is left
+// unfinished.
+func (s *OpenAIStreamConverter) Finish() []string {
+	if !s.started || s.finished || s.stopReason == "" {
+		return nil
+	}
+	return s.finish(s.stopReason, s.anthropicUsage())
+}
 
-		// Send message_stop
-		messageStop := AnthropicStreamEvent{
-			Type: "message_stop",
+// flushToolCalls emits the collected tool calls as complete tool_use blocks
+func (s *OpenAIStreamConverter) flushToolCalls() []string {
+	var events []string
+	for _, call := range s.toolCalls {
+		events = append(events, s.openBlock(&AnthropicContentBlock{
+			Type:  "tool_use",
+			ID:    call.id,
+			Name:  call.name,
+			Input: map[string]interface{}{},
+		})...)
+		if call.arguments.Len() > 0 {
+			events = append(events, s.blockDelta(&AnthropicDelta{
+				Type:        "input_json_delta",
+				PartialJSON: call.arguments.String(),
+			}))
 		}
-		data, _ = json.Marshal(messageStop)
-		events = append(events, string(data))
+		events = append(events, s.closeOpenBlock()...)
 	}
+	s.toolCalls = nil
+	clear(s.toolIndex)
+	return events
+}
 
-	return events, nil
+// anthropicUsage returns the latest upstream usage in Anthropic form; zero until OpenAI reports it
+func (s *OpenAIStreamConverter) anthropicUsage() AnthropicUsage {
+	if s.usage == nil {
+		return AnthropicUsage{}
+	}
+	return AnthropicUsage{
+		InputTokens:  s.usage.PromptTokens,
+		OutputTokens: s.usage.CompletionTokens,
+	}
+}
+
+// mapOpenAIFinishReason maps an OpenAI finish_reason to an Anthropic stop_reason
+func mapOpenAIFinishReason(finishReason string) string {
+	switch finishReason {
+	case "stop":
+		return "end_turn"
+	case "length":
+		return "max_tokens"
+	case "content_filter":
+		return "stop_sequence"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	default:
+		return "end_turn"
+	}
+}
+
+func marshalStreamEvent(event AnthropicStreamEvent) string {
+	data, _ := json.Marshal(event)
+	return string(data)
 }
 
 // GenerateAnthropicMessageID generates an Anthropic-style message ID
@@ -211,6 +287,15 @@ func getToolCallID(toolCallMap map[string]interface{}) string {
 	return fmt.Sprintf("toolu_%d", time.Now().UnixNano())
 }
 
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
 		return val
@@ -328,6 +413,10 @@ func joinTextSegments(segments []string) string {
 	return builder.String()
 }
 
+// defaultAnthropicMaxTokens is used when an OpenAI request does not set a limit,
+// since max_tokens is required by the Anthropic API
+const defaultAnthropicMaxTokens = 4096
+
 // OpenAIToAnthropicRequest converts an OpenAI request to Anthropic format
 func OpenAIToAnthropicRequest(openaiBody []byte) ([]byte, error) {
 	var openaiReq OpenAIRequest
@@ -340,20 +429,48 @@ func OpenAIToAnthropicRequest(openaiBody []byte) ([]byte, error) {
 	anthropicMessages := make([]AnthropicMessage, 0, len(openaiReq.Messages))
 
 	for _, msg := range openaiReq.Messages {
-		if msg.Role == "system" || msg.Role == "developer" {
+		switch msg.Role {
+		case "system", "developer":
 			// Anthropic uses a separate system field
-			contentStr, _ := msg.Content.(string)
+			contentStr := joinTextSegments(ExtractOpenAIText(msg.Content))
 			if systemMessage != "" {
 				systemMessage += "\n\n" + contentStr
 			} else {
 				systemMessage = contentStr
 			}
-		} else {
-			// Convert regular messages (keep as interface{} since Anthropic can handle it)
-			anthropicMessages = append(anthropicMessages, AnthropicMessage{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
+
+		case "tool":
+			// Tool results are user content blocks in Anthropic
+			toolResult := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     joinTextSegments(ExtractOpenAIText(msg.Content)),
+			}
+			anthropicMessages = appendAnthropicMessage(anthropicMessages, "user", []interface{}{toolResult})
+
+		case "assistant":
+			var blocks []interface{}
+			if text := joinTextSegments(ExtractOpenAIText(msg.Content)); text != "" {
+				blocks = append(blocks, map[string]interface{}{
+					"type": "text",
+					"text": text,
+				})
+			}
+			blocks = append(blocks, convertToolCallsToAnthropic(msg.ToolCalls)...)
+			if len(blocks) == 0 {
+				continue
+			}
+			anthropicMessages = appendAnthropicMessage(anthropicMessages, "assistant", blocks)
+
+		default:
+			blocks, err := convertOpenAIContentToAnthropic(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			if len(blocks) == 0 {
+				continue
+			}
+			anthropicMessages = appendAnthropicMessage(anthropicMessages, "user", blocks)
 		}
 	}
 
@@ -365,13 +482,18 @@ func OpenAIToAnthropicRequest(openaiBody []byte) ([]byte, error) {
 		Temperature: openaiReq.Temperature,
 		TopP:        openaiReq.TopP,
 		Stream:      openaiReq.Stream,
-		System:      systemMessage,
+	}
+	if systemMessage != "" {
+		anthropicReq.System = systemMessage
 	}
 
 	// Handle max_completion_tokens (OpenAI) vs max_tokens (Anthropic)
 	if openaiReq.MaxCompletionTokens > 0 && anthropicReq.MaxTokens == 0 {
 		anthropicReq.MaxTokens = openaiReq.MaxCompletionTokens
 	}
+	if anthropicReq.MaxTokens == 0 {
+		anthropicReq.MaxTokens = defaultAnthropicMaxTokens
+	}
 
 	// Convert stop sequences
 	if openaiReq.Stop != nil {
@@ -389,6 +511,31 @@ func OpenAIToAnthropicRequest(openaiBody []byte) ([]byte, error) {
 		}
 	}
 
+	// Convert tools and tool_choice
+	if len(openaiReq.Tools) > 0 {
+		anthropicReq.Tools = convertToolsToAnthropic(openaiReq.Tools)
+	}
+	if openaiReq.ToolChoice != nil {
+		anthropicReq.ToolChoice = convertToolChoiceToAnthropic(openaiReq.ToolChoice)
+	}
+
+	// Convert reasoning_effort to an extended thinking budget, which must stay below max_tokens
+	if budget := thinkingBudgetForEffort(openaiReq.ReasoningEffort); budget > 0 {
+		anthropicReq.Thinking = &map[string]interface{}{
+			"type":          "enabled",
+			"budget_tokens": budget,
+		}
+		if anthropicReq.MaxTokens <= budget {
+			anthropicReq.MaxTokens = budget + defaultAnthropicMaxTokens
+		}
+	}
+
+	if openaiReq.User != "" {
+		anthropicReq.Metadata = &map[string]interface{}{
+			"user_id": openaiReq.User,
+		}
+	}
+
 	// Serialize to JSON
 	anthropicBody, err := json.Marshal(anthropicReq)
 	if err != nil {
@@ -397,3 +544,204 @@ func OpenAIToAnthropicRequest(openaiBody []byte) ([]byte, error) {
 
 	return anthropicBody, nil
 }
+
+// appendAnthropicMessage appends content blocks, merging them into the previous
+// message when it has the same role since Anthropic expects alternating turns
+func appendAnthropicMessage(messages []AnthropicMessage, role string, blocks []interface{}) []AnthropicMessage {
+	if len(messages) > 0 && messages[len(messages)-1].Role == role {
+		last := &messages[len(messages)-1]
+		if existing, ok := last.Content.([]interface{}); ok {
+			last.Content = append(existing, blocks...)
+			return messages
+		}
+	}
+
+	return append(messages, AnthropicMessage{
+		Role:    role,
+		Content: blocks,
+	})
+}
+
+// convertOpenAIContentToAnthropic converts OpenAI user content (string or parts) to Anthropic content blocks
+func convertOpenAIContentToAnthropic(content interface{}) ([]interface{}, error) {
+	if text, ok := content.(string); ok {
+		if text == "" {
+			return nil, nil
+		}
+		return []interface{}{map[string]interface{}{"type": "text", "text": text}}, nil
+	}
+
+	var blocks []interface{}
+	for _, part := range contentBlocks(content) {
+		partMap, ok := part.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		partType, _ := partMap["type"].(string)
+		switch partType {
+		case "text":
+			if text, _ := partMap["text"].(string); text != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+			}
+
+		case "image_url":
+			// image_url is usually {"url": "..."} but some clients send the bare string
+			url, _ := partMap["image_url"].(string)
+			if imageURL, ok := partMap["image_url"].(map[string]interface{}); ok {
+				url, _ = imageURL["url"].(string)
+			}
+			if url == "" {
+				return nil, fmt.Errorf("%w: image_url part requires a url", ErrUnsupportedContent)
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type":   "image",
+				"source": urlToAnthropicSource(url),
+			})
+
+		case "file":
+			file, _ := partMap["file"].(map[string]interface{})
+			fileData, _ := file["file_data"].(string)
+			source := urlToAnthropicSource(fileData)
+			if source["type"] != "base64" {
+				return nil, fmt.Errorf("%w: file parts must carry inline file_data", ErrUnsupportedContent)
+			}
+			document := map[string]interface{}{
+				"type":   "document",
+				"source": source,
+			}
+			if filename, _ := file["filename"].(string); filename != "" {
+				document["title"] = filename
+			}
+			blocks = append(blocks, document)
+
+		default:
+			return nil, fmt.Errorf("%w: content part type %q is not supported", ErrUnsupportedContent, partType)
+		}
+	}
+
+	return blocks, nil
+}
+
+// urlToAnthropicSource converts an OpenAI image URL into an Anthropic source
+// "data:image/png;base64,..." becomes a base64 source, anything else a url source
+func urlToAnthropicSource(url string) map[string]interface{} {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if mediaType, data, ok := strings.Cut(rest, ";base64,"); ok {
+			return map[string]interface{}{
+				"type":       "base64",
+				"media_type": mediaType,
+				"data":       data,
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "url",
+		"url":  url,
+	}
+}
+
+// convertToolCallsToAnthropic converts OpenAI tool_calls to Anthropic tool_use blocks
+func convertToolCallsToAnthropic(toolCalls []interface{}) []interface{} {
+	var blocks []interface{}
+	for _, toolCall := range toolCalls {
+		toolCallMap, ok := toolCall.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		functionData, ok := toolCallMap["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Arguments are a JSON string in OpenAI and an object in Anthropic
+		input := map[string]interface{}{}
+		if argsStr, ok := functionData["arguments"].(string); ok && argsStr != "" {
+			json.Unmarshal([]byte(argsStr), &input)
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    getToolCallID(toolCallMap),
+			"name":  getString(functionData, "name"),
+			"input": input,
+		})
+	}
+	return blocks
+}
+
+// convertToolsToAnthropic converts OpenAI function tools to Anthropic format
+// OpenAI: {"type": "function", "function": {"name": "...", "description": "...", "parameters": {...}}}
+// Anthropic: {"name": "...", "description": "...", "input_schema": {...}}
+func convertToolsToAnthropic(openaiTools []interface{}) []interface{} {
+	anthropicTools := make([]interface{}, 0, len(openaiTools))
+	for _, tool := range openaiTools {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		functionData, ok := toolMap["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		schema := functionData["parameters"]
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+
+		anthropicTool := map[string]interface{}{
+			"name":         functionData["name"],
+			"input_schema": schema,
+		}
+		if description, ok := functionData["description"].(string); ok && description != "" {
+			anthropicTool["description"] = description
+		}
+		anthropicTools = append(anthropicTools, anthropicTool)
+	}
+	return anthropicTools
+}
+
+// convertToolChoiceToAnthropic converts OpenAI tool_choice to Anthropic format
+func convertToolChoiceToAnthropic(openaiToolChoice interface{}) interface{} {
+	// OpenAI tool_choice can be:
+	// - "auto" -> {"type": "auto"}
+	// - "required" -> {"type": "any"}
+	// - "none" -> {"type": "none"}
+	// - {"type": "function", "function": {"name": "..."}} -> {"type": "tool", "name": "..."}
+	switch v := openaiToolChoice.(type) {
+	case string:
+		switch v {
+		case "required":
+			return map[string]interface{}{"type": "any"}
+		case "none":
+			return map[string]interface{}{"type": "none"}
+		default:
+			return map[string]interface{}{"type": "auto"}
+		}
+	case map[string]interface{}:
+		if functionData, ok := v["function"].(map[string]interface{}); ok {
+			return map[string]interface{}{
+				"type": "tool",
+				"name": functionData["name"],
+			}
+		}
+	}
+
+	return map[string]interface{}{"type": "auto"}
+}
+
+// thinkingBudgetForEffort maps an OpenAI reasoning_effort level to an Anthropic thinking budget
+func thinkingBudgetForEffort(effort string) int {
+	switch effort {
+	case "low":
+		return 2048
+	case "medium":
+		return 8192
+	case "high":
+		return 24576
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,260 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// describeEvents reduces converted events to one line each: the event type, the block
+// index and the part of the block or delta that matters for sequencing
+func describeEvents(t *testing.T, events []string) []string {
+	t.Helper()
+
+	var lines []string
+	for _, raw := range events {
+		var event AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			t.Fatalf("invalid event %s: %v", raw, err)
+		}
+
+		line := event.Type
+		if event.Index != nil {
+			line += fmt.Sprintf(" %d", *event.Index)
+		}
+		switch {
+		case event.ContentBlock != nil:
+			line += " " + event.ContentBlock.Type
+			if event.ContentBlock.ID != "" {
+				line += " " + event.ContentBlock.ID + " " + event.ContentBlock.Name
+			}
+		case event.Type == "content_block_delta":
+			line += " " + event.Delta.Text + event.Delta.Thinking + event.Delta.PartialJSON
+		case event.Type == "message_delta":
+			line += " " + event.Delta.StopReason
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// convertAll feeds chunks through a stream converter and ends it with Finish
+func convertAll(t *testing.T, converter StreamConverter, chunks []string) []string {
+	t.Helper()
+
+	var events []string
+	for _, chunk := range chunks {
+		converted, err := converter.Convert([]byte(chunk))
+		if err != nil {
+			t.Fatalf("Convert(%s): %v", chunk, err)
+		}
+		events = append(events, converted...)
+	}
+	return describeEvents(t, append(events, converter.Finish()...))
+}
+
+func TestOpenAIStreamConverter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name: "text then usage chunk",
+			chunks: []string{
+				`{"id":"chatcmpl-1","choices":[{"delta":{"content":"Hel"}}]}`,
+				`{"choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+				`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 Hel",
+				"content_block_delta 0 lo",
+				"content_block_stop 0",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "reasoning then text",
+			chunks: []string{
+				`{"choices":[{"delta":{"reasoning_content":"think"}}]}`,
+				`{"choices":[{"delta":{"content":"answer"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 thinking",
+				"content_block_delta 0 think",
+				"content_block_stop 0",
+				"content_block_start 1 text",
+				"content_block_delta 1 answer",
+				"content_block_stop 1",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "interleaved parallel tool calls",
+			chunks: []string{
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"read","arguments":"{\"pa"}}]}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","function":{"name":"grep","arguments":"{\"q\":"}}]}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"th\":1}"}}]}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":1,"function":{"arguments":"2}"}}]},"finish_reason":"tool_calls"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 tool_use call_a read",
+				`content_block_delta 0 {"path":1}`,
+				"content_block_stop 0",
+				"content_block_start 1 tool_use call_b grep",
+				`content_block_delta 1 {"q":2}`,
+				"content_block_stop 1",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "text between tool call fragments",
+			chunks: []string{
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"read","arguments":"{"}}]}}]}`,
+				`{"choices":[{"delta":{"content":"checking"}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"}"}}]},"finish_reason":"tool_calls"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 checking",
+				"content_block_stop 0",
+				"content_block_start 1 tool_use call_a read",
+				"content_block_delta 1 {}",
+				"content_block_stop 1",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "reused index with a new id starts another call",
+			chunks: []string{
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"read","arguments":"{}"}}]}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_b","function":{"name":"list","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 tool_use call_a read",
+				"content_block_delta 0 {}",
+				"content_block_stop 0",
+				"content_block_start 1 tool_use call_b list",
+				"content_block_delta 1 {}",
+				"content_block_stop 1",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "finish_reason without usage chunk",
+			chunks: []string{
+				`{"choices":[{"delta":{"content":"hi"},"finish_reason":"length"}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 hi",
+				"content_block_stop 0",
+				"message_delta max_tokens",
+				"message_stop",
+			},
+		},
+		{
+			name: "cut off before finish_reason",
+			chunks: []string{
+				`{"choices":[{"delta":{"content":"hi"}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"read","arguments":"{"}}]}}]}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 hi",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertAll(t, NewOpenAIStreamConverter("model"), tt.chunks)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIToAnthropicRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		want    string // Expected Anthropic request
+	}{
+		{
+			name: "system messages and defaults",
+			request: `{"model":"m","messages":[
+				{"role":"system","content":"be brief"},
+				{"role":"developer","content":[{"type":"text","text":"use tools"}]},
+				{"role":"user","content":"hi"}],"stop":"END","user":"u1"}`,
+			want: `{"model":"m","max_tokens":4096,"system":"be brief\n\nuse tools",
+				"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],
+				"stop_sequences":["END"],"metadata":{"user_id":"u1"}}`,
+		},
+		{
+			name: "tool calls and results",
+			request: `{"model":"m","max_completion_tokens":100,"messages":[
+				{"role":"user","content":"read it"},
+				{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"read","arguments":"{\"path\":\"a\"}"}}]},
+				{"role":"tool","tool_call_id":"call_1","content":"file a"},
+				{"role":"user","content":"thanks"}],
+				"tools":[{"type":"function","function":{"name":"read","parameters":{"type":"object"}}}],
+				"tool_choice":{"type":"function","function":{"name":"read"}}}`,
+			want: `{"model":"m","max_tokens":100,"messages":[
+				{"role":"user","content":[{"type":"text","text":"read it"}]},
+				{"role":"assistant","content":[{"type":"tool_use","id":"call_1","name":"read","input":{"path":"a"}}]},
+				{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":"file a"},{"type":"text","text":"thanks"}]}],
+				"tools":[{"name":"read","input_schema":{"type":"object"}}],
+				"tool_choice":{"type":"tool","name":"read"}}`,
+		},
+		{
+			name: "images and files",
+			request: `{"model":"m","max_tokens":10,"messages":[{"role":"user","content":[
+				{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBOR"}},
+				{"type":"image_url","image_url":"https://example.com/a.png"},
+				{"type":"file","file":{"filename":"spec.pdf","file_data":"data:application/pdf;base64,JVBER"}}]}]}`,
+			want: `{"model":"m","max_tokens":10,"messages":[{"role":"user","content":[
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}},
+				{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}},
+				{"type":"document","title":"spec.pdf","source":{"type":"base64","media_type":"application/pdf","data":"JVBER"}}]}]}`,
+		},
+		{
+			name:    "reasoning effort raises max_tokens above the budget",
+			request: `{"model":"m","max_tokens":1000,"reasoning_effort":"medium","messages":[{"role":"user","content":"hi"}]}`,
+			want: `{"model":"m","max_tokens":12288,"thinking":{"type":"enabled","budget_tokens":8192},
+				"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := OpenAIToAnthropicRequest([]byte(tt.request))
+			if err != nil {
+				t.Fatalf("OpenAIToAnthropicRequest: %v", err)
+			}
+			var got interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("invalid Anthropic request %s: %v", body, err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("request:\n got  %s\n want %s", body, tt.want)
+			}
+		})
+	}
+}
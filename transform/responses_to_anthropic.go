@@ -0,0 +1,267 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResponsesToAnthropicResponse converts an OpenAI Responses API response to Anthropic format
+func ResponsesToAnthropicResponse(responsesBody []byte, model string) ([]byte, error) {
+	var responsesResp ResponsesResponse
+	if err := json.Unmarshal(responsesBody, &responsesResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Responses response: %w", err)
+	}
+
+	anthropicResp := AnthropicResponse{
+		ID:    responsesResp.ID,
+		Type:  "message",
+		Role:  "assistant",
+		Model: model,
+		Usage: responsesUsageToAnthropic(responsesResp.Usage),
+	}
+
+	// Convert output items to content blocks, keeping their order
+	contentBlocks := []AnthropicContentBlock{}
+	hasToolCall := false
+	for _, item := range responsesResp.Output {
+		switch item.Type {
+		case "reasoning":
+			if thinking := responsesReasoningText(item); thinking != "" {
+				contentBlocks = append(contentBlocks, AnthropicContentBlock{
+					Type:     "thinking",
+					Thinking: thinking,
+				})
+			}
+
+		case "message":
+			for _, part := range item.Content {
+				text := firstNonEmpty(part.Text, part.Refusal)
+				if text == "" {
+					continue
+				}
+				contentBlocks = append(contentBlocks, AnthropicContentBlock{
+					Type: "text",
+					Text: text,
+				})
+			}
+
+		case "function_call":
+			hasToolCall = true
+			input := map[string]interface{}{}
+			if item.Arguments != "" {
+				json.Unmarshal([]byte(item.Arguments), &input)
+			}
+			contentBlocks = append(contentBlocks, AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    firstNonEmpty(item.CallID, item.ID),
+				Name:  item.Name,
+				Input: input,
+			})
+		}
+	}
+	anthropicResp.Content = contentBlocks
+	anthropicResp.StopReason = responsesStopReason(responsesResp, hasToolCall)
+
+	// Serialize to JSON
+	anthropicBody, err := json.Marshal(anthropicResp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Anthropic response: %w", err)
+	}
+
+	return anthropicBody, nil
+}
+
+// ResponsesStreamConverter converts an OpenAI Responses API event stream into
+// Anthropic SSE events. Output items stream one after another, so each item
+// maps to at most one content block: reasoning to thinking, message to text
+// and function_call to tool_use.
+type ResponsesStreamConverter struct {
+	anthropicStream
+	openOutput  int  // Responses output_index of the open block
+	openArgs    bool // Whether the open tool_use block received argument deltas
+	hasToolCall bool
+}
+
+// NewResponsesStreamConverter creates a converter for a single Responses stream
+func NewResponsesStreamConverter(model string) *ResponsesStreamConverter {
+	return &ResponsesStreamConverter{
+		anthropicStream: newAnthropicStream(model),
+		openOutput:      -1,
+	}
+}
+
+// Convert converts one Responses stream event into zero or more Anthropic events
+func (s *ResponsesStreamConverter) Convert(responsesEvent []byte) ([]string, error) {
+	var event ResponsesStreamEvent
+	if err := json.Unmarshal(responsesEvent, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse Responses stream event: %w", err)
+	}
+
+	var events []string
+
+	if s.finished {
+		return events, nil
+	}
+
+	if !s.started {
+		id := ""
+		if event.Response != nil {
+			id = event.Response.ID
+		}
+		events = append(events, s.start(id, AnthropicUsage{})...)
+	}
+
+	switch event.Type {
+	case "response.output_item.added":
+		if event.Item != nil && event.Item.Type == "function_call" {
+			s.hasToolCall = true
+			events = append(events, s.openItemBlock(event.OutputIndex, &AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    firstNonEmpty(event.Item.CallID, event.Item.ID),
+				Name:  event.Item.Name,
+				Input: map[string]interface{}{},
+			})...)
+		}
+
+	case "response.output_text.delta", "response.refusal.delta":
+		if event.Delta == "" {
+			break
+		}
+		if s.openType != "text" || s.openOutput != event.OutputIndex {
+			events = append(events, s.openItemBlock(event.OutputIndex, &AnthropicContentBlock{
+				Type: "text",
+			})...)
+		}
+		events = append(events, s.blockDelta(&AnthropicDelta{
+			Type: "text_delta",
+			Text: event.Delta,
+		}))
+
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		if event.Delta == "" {
+			break
+		}
+		if s.openType != "thinking" || s.openOutput != event.OutputIndex {
+			events = append(events, s.openItemBlock(event.OutputIndex, &AnthropicContentBlock{
+				Type: "thinking",
+			})...)
+		}
+		events = append(events, s.blockDelta(&AnthropicDelta{
+			Type:     "thinking_delta",
+			Thinking: event.Delta,
+		}))
+
+	case "response.function_call_arguments.delta":
+		if s.openType != "tool_use" || s.openOutput != event.OutputIndex || event.Delta == "" {
+			break
+		}
+		s.openArgs = true
+		events = append(events, s.blockDelta(&AnthropicDelta{
+			Type:        "input_json_delta",
+			PartialJSON: event.Delta,
+		}))
+
+	case "response.output_item.done":
+		if s.openIndex == -1 || s.openOutput != event.OutputIndex {
+			break
+		}
+		// Some gateways only send the arguments with the finished item
+		if s.openType == "tool_use" && !s.openArgs && event.Item != nil && event.Item.Arguments != "" {
+			events = append(events, s.blockDelta(&AnthropicDelta{
+				Type:        "input_json_delta",
+				PartialJSON: event.Item.Arguments,
+			}))
+		}
+		events = append(events, s.closeOpenBlock()...)
+
+	case "response.completed", "response.incomplete":
+		stopReason := "end_turn"
+		var usage *ResponsesUsage
+		if event.Response != nil {
+			stopReason = responsesStopReason(*event.Response, s.hasToolCall)
+			usage = event.Response.Usage
+		}
+		events = append(events, s.finish(stopReason, responsesUsageToAnthropic(usage))...)
+
+	case "response.failed", "error":
+		message := event.Message
+		if event.Response != nil && event.Response.Error != nil {
+			message = event.Response.Error.Message
+		}
+		s.finished = true
+		events = append(events, marshalAnthropicError("api_error", "upstream response failed: "+message))
+	}
+
+	return events, nil
+}
+
+// Finish adds nothing: response.completed ends the message, and a stream that
+// stops before it was cut off
+func (s *ResponsesStreamConverter) Finish() []string {
+	return nil
+}
+
+// openItemBlock opens a new content block for a Responses output item
+func (s *ResponsesStreamConverter) openItemBlock(outputIndex int, block *AnthropicContentBlock) []string {
+	s.openOutput = outputIndex
+	s.openArgs = false
+	return s.openBlock(block)
+}
+
+// responsesReasoningText returns the summary of a reasoning item, or its raw reasoning text
+func responsesReasoningText(item ResponsesOutputItem) string {
+	var texts []string
+	for _, part := range item.Summary {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	if len(texts) == 0 {
+		for _, part := range item.Content {
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// responsesStopReason derives an Anthropic stop_reason from a Responses response
+func responsesStopReason(resp ResponsesResponse, hasToolCall bool) string {
+	if resp.Status == "incomplete" && resp.IncompleteDetails != nil {
+		switch resp.IncompleteDetails.Reason {
+		case "max_output_tokens":
+			return "max_tokens"
+		case "content_filter":
+			return "stop_sequence"
+		}
+	}
+	if hasToolCall {
+		return "tool_use"
+	}
+	return "end_turn"
+}
+
+// responsesUsageToAnthropic converts Responses usage to Anthropic usage
+func responsesUsageToAnthropic(usage *ResponsesUsage) AnthropicUsage {
+	if usage == nil {
+		return AnthropicUsage{}
+	}
+	return AnthropicUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+	}
+}
+
+// marshalAnthropicError builds an Anthropic error stream event
+func marshalAnthropicError(errorType, message string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type": "error",
+		"error": map[string]interface{}{
+			"type":    errorType,
+			"message": message,
+		},
+	})
+	return string(data)
+}
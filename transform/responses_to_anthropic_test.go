@@ -0,0 +1,112 @@
+package transform
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResponsesStreamConverter(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   []string
+	}{
+		{
+			name: "reasoning then text",
+			events: []string{
+				`{"type":"response.created","response":{"id":"resp_1"}}`,
+				`{"type":"response.reasoning_summary_text.delta","output_index":0,"delta":"think"}`,
+				`{"type":"response.output_item.done","output_index":0,"item":{"type":"reasoning"}}`,
+				`{"type":"response.output_text.delta","output_index":1,"delta":"Hi"}`,
+				`{"type":"response.output_text.delta","output_index":1,"delta":"!"}`,
+				`{"type":"response.output_item.done","output_index":1,"item":{"type":"message"}}`,
+				`{"type":"response.completed","response":{"id":"resp_1","status":"completed","usage":{"input_tokens":5,"output_tokens":3}}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 thinking",
+				"content_block_delta 0 think",
+				"content_block_stop 0",
+				"content_block_start 1 text",
+				"content_block_delta 1 Hi",
+				"content_block_delta 1 !",
+				"content_block_stop 1",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "function call with streamed arguments",
+			events: []string{
+				`{"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"read"}}`,
+				`{"type":"response.function_call_arguments.delta","output_index":0,"delta":"{\"path\":"}`,
+				`{"type":"response.function_call_arguments.delta","output_index":0,"delta":"1}"}`,
+				`{"type":"response.output_item.done","output_index":0,"item":{"type":"function_call","arguments":"{\"path\":1}"}}`,
+				`{"type":"response.completed","response":{"status":"completed"}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 tool_use call_1 read",
+				`content_block_delta 0 {"path":`,
+				"content_block_delta 0 1}",
+				"content_block_stop 0",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "function call arguments only on the finished item",
+			events: []string{
+				`{"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","id":"fc_1","name":"read"}}`,
+				`{"type":"response.output_item.done","output_index":0,"item":{"type":"function_call","arguments":"{}"}}`,
+				`{"type":"response.completed","response":{"status":"completed"}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 tool_use fc_1 read",
+				"content_block_delta 0 {}",
+				"content_block_stop 0",
+				"message_delta tool_use",
+				"message_stop",
+			},
+		},
+		{
+			name: "incomplete at max output tokens",
+			events: []string{
+				`{"type":"response.output_text.delta","output_index":0,"delta":"Hi"}`,
+				`{"type":"response.incomplete","response":{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 Hi",
+				"content_block_stop 0",
+				"message_delta max_tokens",
+				"message_stop",
+			},
+		},
+		{
+			name: "failed response",
+			events: []string{
+				`{"type":"response.output_text.delta","output_index":0,"delta":"Hi"}`,
+				`{"type":"response.failed","response":{"status":"failed","error":{"message":"boom"}}}`,
+				`{"type":"response.completed","response":{"status":"completed"}}`,
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_delta 0 Hi",
+				"error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertAll(t, NewResponsesStreamConverter("model"), tt.events)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events:\n got  %q\n want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package transform
+
+// anthropicStream builds the Anthropic event sequence for converted streams:
+// message_start, content blocks opened and closed one at a time, then
+// message_delta and message_stop. The OpenAI, Responses and Gemini stream
+// converters embed it and only decide which blocks to open.
+type anthropicStream struct {
+	model     string
+	started   bool
+	finished  bool
+	nextIndex int    // Index of the next content block to open
+	openIndex int    // Index of the currently open block, -1 if none
+	openType  string // Type of the currently open block
+}
+
+func newAnthropicStream(model string) anthropicStream {
+	return anthropicStream{
+		model:     model,
+		openIndex: -1,
+	}
+}
+
+// blockIndex returns the index of a content_block_* event, which other events leave out
+func blockIndex(index int) *int {
+	return &index
+}
+
+// start emits the message_start event with the usage known so far, usually none
+func (s *anthropicStream) start(id string, usage AnthropicUsage) []string {
+	s.started = true
+	if id == "" {
+		id = GenerateAnthropicMessageID()
+	}
+
+	return []string{marshalStreamEvent(AnthropicStreamEvent{
+		Type: "message_start",
+		Message: &AnthropicResponse{
+			ID:      id,
+			Type:    "message",
+			Role:    "assistant",
+			Content: []AnthropicContentBlock{},
+			Model:   s.model,
+			Usage:   usage,
+		},
+	})}
+}
+
+// openBlock closes the open block, if any, and starts a new one
+func (s *anthropicStream) openBlock(block *AnthropicContentBlock) []string {
+	events := s.closeOpenBlock()
+
+	s.openIndex = s.nextIndex
+	s.nextIndex++
+	s.openType = block.Type
+
+	return append(events, marshalStreamEvent(AnthropicStreamEvent{
+		Type:         "content_block_start",
+		Index:        blockIndex(s.openIndex),
+		ContentBlock: block,
+	}))
+}
+
+// closeOpenBlock emits content_block_stop for the currently open block, if any
+func (s *anthropicStream) closeOpenBlock() []string {
+	if s.openIndex == -1 {
+		return nil
+	}
+
+	event := marshalStreamEvent(AnthropicStreamEvent{
+		Type:  "content_block_stop",
+		Index: blockIndex(s.openIndex),
+	})
+	s.openIndex = -1
+	s.openType = ""
+	return []string{event}
+}
+
+// Finished reports whether message_stop was emitted, or the stream ended with an error event
+func (s *anthropicStream) Finished() bool {
+	return s.finished
+}
+
+// blockDelta emits a content_block_delta for the open block
+func (s *anthropicStream) blockDelta(delta *AnthropicDelta) string {
+	return marshalStreamEvent(AnthropicStreamEvent{
+		Type:  "content_block_delta",
+		Index: blockIndex(s.openIndex),
+		Delta: delta,
+	})
+}
+
+// finish closes any open block and emits message_delta and message_stop.
+// Usage is only known at the end of these streams, so input_tokens is sent here as well.
+func (s *anthropicStream) finish(stopReason string, usage AnthropicUsage) []string {
+	events := s.closeOpenBlock()
+	s.finished = true
+
+	events = append(events, marshalStreamEvent(AnthropicStreamEvent{
+		Type: "message_delta",
+		Delta: &AnthropicDelta{
+			StopReason: stopReason,
+		},
+		Usage: &usage,
+	}))
+
+	events = append(events, marshalStreamEvent(AnthropicStreamEvent{
+		Type: "message_stop",
+	}))
+
+	return events
+}
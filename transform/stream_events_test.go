@@ -0,0 +1,99 @@
+package transform
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestAnthropicStreamSequencing(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(s *anthropicStream) []string
+		want  []string
+	}{
+		{
+			name: "blocks get consecutive indices",
+			steps: func(s *anthropicStream) []string {
+				events := s.start("msg_1", AnthropicUsage{})
+				events = append(events, s.openBlock(&AnthropicContentBlock{Type: "thinking"})...)
+				events = append(events, s.blockDelta(&AnthropicDelta{Type: "thinking_delta", Thinking: "hmm"}))
+				events = append(events, s.openBlock(&AnthropicContentBlock{Type: "text"})...)
+				events = append(events, s.blockDelta(&AnthropicDelta{Type: "text_delta", Text: "hi"}))
+				return append(events, s.finish("end_turn", AnthropicUsage{OutputTokens: 2})...)
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 thinking",
+				"content_block_delta 0 hmm",
+				"content_block_stop 0",
+				"content_block_start 1 text",
+				"content_block_delta 1 hi",
+				"content_block_stop 1",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "closing twice emits one stop",
+			steps: func(s *anthropicStream) []string {
+				events := s.start("", AnthropicUsage{})
+				events = append(events, s.openBlock(&AnthropicContentBlock{Type: "text"})...)
+				events = append(events, s.closeOpenBlock()...)
+				events = append(events, s.closeOpenBlock()...)
+				return append(events, s.finish("end_turn", AnthropicUsage{})...)
+			},
+			want: []string{
+				"message_start",
+				"content_block_start 0 text",
+				"content_block_stop 0",
+				"message_delta end_turn",
+				"message_stop",
+			},
+		},
+		{
+			name: "message without content",
+			steps: func(s *anthropicStream) []string {
+				events := s.start("", AnthropicUsage{})
+				return append(events, s.finish("max_tokens", AnthropicUsage{})...)
+			},
+			want: []string{
+				"message_start",
+				"message_delta max_tokens",
+				"message_stop",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAnthropicStream("model")
+			got := describeEvents(t, tt.steps(&s))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events:\n got  %q\n want %q", got, tt.want)
+			}
+			if !s.Finished() {
+				t.Error("stream not finished after finish")
+			}
+		})
+	}
+}
+
+func TestAnthropicStreamStart(t *testing.T) {
+	s := newAnthropicStream("model")
+	events := s.start("", AnthropicUsage{InputTokens: 12})
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	var event AnthropicStreamEvent
+	if err := json.Unmarshal([]byte(events[0]), &event); err != nil {
+		t.Fatalf("invalid event %s: %v", events[0], err)
+	}
+	if event.Index != nil {
+		t.Errorf("message_start carries index %d", *event.Index)
+	}
+	if event.Message == nil || event.Message.ID == "" || event.Message.Model != "model" || event.Message.Usage.InputTokens != 12 {
+		t.Errorf("message_start message = %+v", event.Message)
+	}
+}
@@ -1,11 +1,28 @@
 package transform
 
+import "encoding/json"
+
 // Provider types
 const (
 	ProviderTypeAnthropic = "anthropic"
 	ProviderTypeOpenAI    = "openai"
+	ProviderTypeResponses = "responses" // OpenAI Responses API (/v1/responses)
+	ProviderTypeGemini    = "gemini"    // Google Gemini API (generateContent)
+	ProviderTypeBedrock   = "bedrock"   // Anthropic models on AWS Bedrock (InvokeModel)
+	ProviderTypeVertex    = "vertex"    // Anthropic models on Google Vertex AI (rawPredict)
 )
 
+// StreamConverter converts a provider's streamed chunks into Anthropic SSE events
+type StreamConverter interface {
+	// Convert converts the data of one upstream SSE event into zero or more Anthropic events
+	Convert(chunk []byte) ([]string, error)
+	// Finish completes a message the upstream ended without sending all of it, such
+	// as usage after the finish reason; it adds nothing to a stream that was cut off
+	Finish() []string
+	// Finished reports whether the Anthropic message is complete
+	Finished() bool
+}
+
 // Anthropic Request/Response Types
 type AnthropicRequest struct {
 	Model       string                   `json:"model"`
@@ -29,11 +46,34 @@ type AnthropicMessage struct {
 }
 
 type AnthropicContentBlock struct {
-	Type  string                 `json:"type"`
-	Text  string                 `json:"text,omitempty"`
-	ID    string                 `json:"id,omitempty"`    // For tool_use blocks
-	Name  string                 `json:"name,omitempty"`  // For tool_use blocks
-	Input map[string]interface{} `json:"input,omitempty"` // For tool_use blocks
+	Type      string      `json:"type"`
+	Text      string      `json:"text,omitempty"`
+	ID        string      `json:"id,omitempty"`        // For tool_use blocks
+	Name      string      `json:"name,omitempty"`      // For tool_use blocks
+	Input     interface{} `json:"input,omitempty"`     // For tool_use blocks (a non-nil empty map is kept when streaming)
+	Thinking  string      `json:"thinking,omitempty"`  // For thinking blocks
+	Signature string      `json:"signature,omitempty"` // For thinking blocks
+}
+
+// MarshalJSON always emits the text field for text blocks (and thinking and
+// signature for thinking blocks), since clients append deltas to them and
+// expect them to exist even when empty
+func (b AnthropicContentBlock) MarshalJSON() ([]byte, error) {
+	type block AnthropicContentBlock
+	switch b.Type {
+	case "text":
+		return json.Marshal(struct {
+			block
+			Text string `json:"text"`
+		}{block(b), b.Text})
+	case "thinking":
+		return json.Marshal(struct {
+			block
+			Thinking  string `json:"thinking"`
+			Signature string `json:"signature"`
+		}{block(b), b.Thinking, b.Signature})
+	}
+	return json.Marshal(block(b))
 }
 
 type AnthropicResponse struct {
@@ -48,8 +88,10 @@ type AnthropicResponse struct {
 }
 
 type AnthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // OpenAI Request/Response Types
@@ -62,6 +104,7 @@ type OpenAIRequest struct {
 	TopP             *float64                 `json:"top_p,omitempty"`
 	N                int                      `json:"n,omitempty"`
 	Stream           bool                     `json:"stream,omitempty"`
+	StreamOptions    *OpenAIStreamOptions     `json:"stream_options,omitempty"`
 	Stop             interface{}              `json:"stop,omitempty"` // Can be string or []string
 	Tools            []interface{}            `json:"tools,omitempty"`
 	ToolChoice       interface{}              `json:"tool_choice,omitempty"`
@@ -69,6 +112,12 @@ type OpenAIRequest struct {
 	FrequencyPenalty *float64                 `json:"frequency_penalty,omitempty"`
 	LogitBias        map[string]float64       `json:"logit_bias,omitempty"`
 	User             string                   `json:"user,omitempty"`
+	ReasoningEffort  string                   `json:"reasoning_effort,omitempty"` // "low", "medium" or "high"
+	Reasoning        map[string]interface{}   `json:"reasoning,omitempty"`        // OpenRouter-style reasoning config
+}
+
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type OpenAIMessage struct {
@@ -77,6 +126,10 @@ type OpenAIMessage struct {
 	Name       string        `json:"name,omitempty"`
 	ToolCalls  []interface{} `json:"tool_calls,omitempty"`
 	ToolCallID string        `json:"tool_call_id,omitempty"` // For tool role messages
+
+	// Reasoning output, named reasoning_content by DeepSeek-style APIs and reasoning by OpenRouter-style APIs
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
 }
 
 type OpenAIResponse struct {
@@ -97,9 +150,14 @@ type OpenAIChoice struct {
 }
 
 type OpenAIUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens        int                        `json:"prompt_tokens"`
+	CompletionTokens    int                        `json:"completion_tokens"`
+	TotalTokens         int                        `json:"total_tokens"`
+	PromptTokensDetails *OpenAIPromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+type OpenAIPromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
 }
 
 // OpenAI Streaming Types
@@ -109,6 +167,7 @@ type OpenAIStreamChunk struct {
 	Created int64                    `json:"created"`
 	Model   string                   `json:"model"`
 	Choices []OpenAIStreamChoice     `json:"choices"`
+	Usage   *OpenAIUsage             `json:"usage,omitempty"` // Final chunk when stream_options.include_usage is set
 }
 
 type OpenAIStreamChoice struct {
@@ -118,23 +177,219 @@ type OpenAIStreamChoice struct {
 }
 
 type OpenAIStreamDelta struct {
-	Role    string      `json:"role,omitempty"`
-	Content interface{} `json:"content,omitempty"`
+	Role      string                 `json:"role,omitempty"`
+	Content   interface{}            `json:"content,omitempty"`
+	ToolCalls []OpenAIStreamToolCall `json:"tool_calls,omitempty"`
+
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+}
+
+// OpenAIStreamToolCall is an incremental tool_call fragment. Only the first
+// fragment of a call carries the ID and function name; later fragments append
+// to the arguments string of the call with the same index.
+type OpenAIStreamToolCall struct {
+	Index    int                      `json:"index"`
+	ID       string                   `json:"id,omitempty"`
+	Type     string                   `json:"type,omitempty"`
+	Function OpenAIStreamFunctionCall `json:"function"`
+}
+
+type OpenAIStreamFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
 }
 
 // Anthropic Streaming Types
 type AnthropicStreamEvent struct {
 	Type          string                      `json:"type"`
 	Message       *AnthropicResponse          `json:"message,omitempty"`
-	Index         int                         `json:"index,omitempty"`
+	Index         *int                        `json:"index,omitempty"` // Only set on content_block_* events
 	ContentBlock  *AnthropicContentBlock      `json:"content_block,omitempty"`
 	Delta         *AnthropicDelta             `json:"delta,omitempty"`
 	Usage         *AnthropicUsage             `json:"usage,omitempty"`
 }
 
+// BlockIndex returns the content block index of a content_block_* event
+func (e AnthropicStreamEvent) BlockIndex() int {
+	if e.Index == nil {
+		return 0
+	}
+	return *e.Index
+}
+
 type AnthropicDelta struct {
 	Type         string `json:"type,omitempty"`
 	Text         string `json:"text,omitempty"`
+	PartialJSON  string `json:"partial_json,omitempty"` // For input_json_delta
+	Thinking     string `json:"thinking,omitempty"`     // For thinking_delta
 	StopReason   string `json:"stop_reason,omitempty"`
 	StopSequence string `json:"stop_sequence,omitempty"`
 }
+
+// OpenAI Responses API Types
+type ResponsesRequest struct {
+	Model           string                 `json:"model"`
+	Input           []interface{}          `json:"input"`
+	Instructions    string                 `json:"instructions,omitempty"`
+	MaxOutputTokens int                    `json:"max_output_tokens,omitempty"`
+	Temperature     *float64               `json:"temperature,omitempty"`
+	TopP            *float64               `json:"top_p,omitempty"`
+	Stream          bool                   `json:"stream,omitempty"`
+	Tools           []interface{}          `json:"tools,omitempty"`
+	ToolChoice      interface{}            `json:"tool_choice,omitempty"`
+	Reasoning       map[string]interface{} `json:"reasoning,omitempty"`
+	Store           bool                   `json:"store"` // Always false, the proxy is stateless
+}
+
+type ResponsesResponse struct {
+	ID                string                      `json:"id"`
+	Status            string                      `json:"status"` // "completed", "incomplete" or "failed"
+	Model             string                      `json:"model"`
+	Output            []ResponsesOutputItem       `json:"output"`
+	IncompleteDetails *ResponsesIncompleteDetails `json:"incomplete_details,omitempty"`
+	Error             *ResponsesError             `json:"error,omitempty"`
+	Usage             *ResponsesUsage             `json:"usage,omitempty"`
+}
+
+type ResponsesOutputItem struct {
+	Type      string                 `json:"type"` // "message", "function_call" or "reasoning"
+	ID        string                 `json:"id,omitempty"`
+	Role      string                 `json:"role,omitempty"`
+	Content   []ResponsesContentPart `json:"content,omitempty"`
+	Summary   []ResponsesContentPart `json:"summary,omitempty"` // Reasoning summaries
+	CallID    string                 `json:"call_id,omitempty"` // For function_call items
+	Name      string                 `json:"name,omitempty"`
+	Arguments string                 `json:"arguments,omitempty"`
+}
+
+type ResponsesContentPart struct {
+	Type    string `json:"type"` // "output_text", "refusal", "summary_text" or "reasoning_text"
+	Text    string `json:"text,omitempty"`
+	Refusal string `json:"refusal,omitempty"`
+}
+
+type ResponsesIncompleteDetails struct {
+	Reason string `json:"reason"` // e.g. "max_output_tokens" or "content_filter"
+}
+
+type ResponsesError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ResponsesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ResponsesStreamEvent covers the fields used from Responses API stream events
+// (response.created, response.output_item.added, response.output_text.delta, ...)
+type ResponsesStreamEvent struct {
+	Type        string               `json:"type"`
+	Response    *ResponsesResponse   `json:"response,omitempty"`
+	OutputIndex int                  `json:"output_index"`
+	Item        *ResponsesOutputItem `json:"item,omitempty"`
+	Delta       string               `json:"delta,omitempty"`
+	Code        string               `json:"code,omitempty"`    // For error events
+	Message     string               `json:"message,omitempty"` // For error events
+}
+
+// Gemini Types
+type GeminiRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig        *GeminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // "user" or "model"
+	Parts []GeminiPart `json:"parts"`
+}
+
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"` // Text is a thought summary
+	InlineData       *GeminiBlob             `json:"inlineData,omitempty"`
+	FileData         *GeminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type GeminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // Base64 encoded
+}
+
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type GeminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+type GeminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type GeminiFunctionDeclaration struct {
+	Name                 string      `json:"name"`
+	Description          string      `json:"description,omitempty"`
+	ParametersJSONSchema interface{} `json:"parametersJsonSchema,omitempty"` // Full JSON Schema, unlike "parameters"
+}
+
+type GeminiToolConfig struct {
+	FunctionCallingConfig GeminiFunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+type GeminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"` // "AUTO", "ANY" or "NONE"
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type GeminiGenerationConfig struct {
+	MaxOutputTokens int                   `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64              `json:"temperature,omitempty"`
+	TopP            *float64              `json:"topP,omitempty"`
+	TopK            *int                  `json:"topK,omitempty"`
+	StopSequences   []string              `json:"stopSequences,omitempty"`
+	ThinkingConfig  *GeminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type GeminiThinkingConfig struct {
+	ThinkingBudget  int  `json:"thinkingBudget"`
+	IncludeThoughts bool `json:"includeThoughts"`
+}
+
+// GeminiResponse is both the generateContent response and each streamGenerateContent chunk
+type GeminiResponse struct {
+	Candidates    []GeminiCandidate    `json:"candidates"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+	ResponseID    string               `json:"responseId,omitempty"`
+	ModelVersion  string               `json:"modelVersion,omitempty"`
+}
+
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"` // "STOP", "MAX_TOKENS", "SAFETY", ...
+}
+
+type GeminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
@@ -56,7 +56,7 @@ func (p *ConfigPage) Update() {
 	} else {
 		for name, provider := range p.cfg.Spec.Providers {
 			builder.WriteString(fmt.Sprintf("  [green]%s[white]\n", name))
-			builder.WriteString(fmt.Sprintf("    Endpoint: [grey]%s[white]\n", provider.Endpoint))
+			builder.WriteString(fmt.Sprintf("    Endpoint: [grey]%s[white]\n", provider.GetEndpoint()))
 
 			// Mask API key for security
 			apiKeyDisplay := maskAPIKey(provider.APIKey)
@@ -108,7 +108,7 @@ func (p *ConfigPage) Update() {
 
 	// Routing info
 	builder.WriteString("[cyan::b]Routing Logic:[white]\n")
-	builder.WriteString("  1. Models with TPS < 40 are excluded\n")
+	builder.WriteString("  1. Models with TPS < 40 are excluded (tried last with -slow-providers last)\n")
 	builder.WriteString("  2. Models are sorted by weight (higher first)\n")
 	builder.WriteString("  3. If weights are equal, higher TPS wins\n")
 	builder.WriteString("  4. On failure, immediately tries the next provider (unless same-provider retries are enabled)\n")
@@ -150,7 +150,7 @@ func (p *OverviewPage) updateMetricsTable() {
 	p.metricsTable.Clear()
 
 	// Set headers
-	headers := []string{"Provider", "Model", "TPS", "Samples", "Weight", "Status"}
+	headers := []string{"Provider", "Model", "TPS", "TTFT", "Samples", "Hedges W/L", "Weight", "Status"}
 	for col, header := range headers {
 		cell := tview.NewTableCell(header).
 			SetTextColor(tcell.ColorYellow).
@@ -199,9 +199,15 @@ func (p *OverviewPage) updateMetricsTable() {
 		p.metricsTable.SetCell(row, 0, tview.NewTableCell(metricData.ProviderName).SetAlign(tview.AlignLeft))
 		p.metricsTable.SetCell(row, 1, tview.NewTableCell(truncateString(metricData.ModelName, 30)).SetAlign(tview.AlignLeft))
 		p.metricsTable.SetCell(row, 2, tview.NewTableCell(fmt.Sprintf("%.2f", metricData.TPS)).SetAlign(tview.AlignRight))
-		p.metricsTable.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("%d", len(metricData.Samples))).SetAlign(tview.AlignCenter))
-		p.metricsTable.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%d", weight)).SetAlign(tview.AlignCenter))
-		p.metricsTable.SetCell(row, 5, tview.NewTableCell(status).SetTextColor(statusColor).SetAlign(tview.AlignCenter))
+		ttft := "-"
+		if metricData.TTFT > 0 {
+			ttft = fmt.Sprintf("%.2fs", metricData.TTFT.Seconds())
+		}
+		p.metricsTable.SetCell(row, 3, tview.NewTableCell(ttft).SetAlign(tview.AlignRight))
+		p.metricsTable.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%d", len(metricData.Samples))).SetAlign(tview.AlignCenter))
+		p.metricsTable.SetCell(row, 5, tview.NewTableCell(fmt.Sprintf("%d/%d", metricData.HedgeWins, metricData.HedgeLosses)).SetAlign(tview.AlignCenter))
+		p.metricsTable.SetCell(row, 6, tview.NewTableCell(fmt.Sprintf("%d", weight)).SetAlign(tview.AlignCenter))
+		p.metricsTable.SetCell(row, 7, tview.NewTableCell(status).SetTextColor(statusColor).SetAlign(tview.AlignCenter))
 
 		row++
 	}
@@ -300,7 +306,7 @@ func (p *OverviewPage) updateProvidersTable() {
 	p.providersTable.Clear()
 
 	// Set headers
-	headers := []string{"Provider", "Total Requests", "Success", "Errors", "Error Rate", "Last Error", "Status Code"}
+	headers := []string{"Provider", "Total Requests", "Success", "Errors", "Error Rate", "Last Error", "Status Code", "Circuit"}
 	for col, header := range headers {
 		cell := tview.NewTableCell(header).
 			SetTextColor(tcell.ColorYellow).
@@ -310,6 +316,18 @@ func (p *OverviewPage) updateProvidersTable() {
 		p.providersTable.SetCell(0, col, cell)
 	}
 
+	// Count circuit breaker states per provider
+	openCircuits := make(map[string]int)
+	halfOpenCircuits := make(map[string]int)
+	for _, breaker := range p.errorTracker.Breaker().GetAll() {
+		switch breaker.State {
+		case metrics.BreakerOpen:
+			openCircuits[breaker.ProviderName]++
+		case metrics.BreakerHalfOpen:
+			halfOpenCircuits[breaker.ProviderName]++
+		}
+	}
+
 	// Get all providers
 	providers := p.providerManager.GetAll()
 
@@ -382,6 +400,15 @@ func (p *OverviewPage) updateProvidersTable() {
 		p.providersTable.SetCell(row, 5, tview.NewTableCell(lastError).SetAlign(tview.AlignCenter))
 		p.providersTable.SetCell(row, 6, tview.NewTableCell(statusCode).SetAlign(tview.AlignCenter))
 
+		// Show the worst breaker state across the provider's models
+		circuit := "[green]CLOSED[white]"
+		if open := openCircuits[prov.Name]; open > 0 {
+			circuit = fmt.Sprintf("[red]OPEN (%d)[white]", open)
+		} else if halfOpen := halfOpenCircuits[prov.Name]; halfOpen > 0 {
+			circuit = fmt.Sprintf("[yellow]HALF-OPEN (%d)[white]", halfOpen)
+		}
+		p.providersTable.SetCell(row, 7, tview.NewTableCell(circuit).SetAlign(tview.AlignCenter))
+
 		row++
 	}
 
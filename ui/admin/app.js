@@ -353,6 +353,8 @@ function displayAnalytics(data) {
                         <th class="px-6 py-4 text-left text-xs font-semibold text-apex-text uppercase tracking-wider">Total Tokens</th>
                         <th class="px-6 py-4 text-left text-xs font-semibold text-apex-text uppercase tracking-wider">Input Tokens</th>
                         <th class="px-6 py-4 text-left text-xs font-semibold text-apex-text uppercase tracking-wider">Output Tokens</th>
+                        <th class="px-6 py-4 text-left text-xs font-semibold text-apex-text uppercase tracking-wider">Cache Write</th>
+                        <th class="px-6 py-4 text-left text-xs font-semibold text-apex-text uppercase tracking-wider">Cache Read</th>
                     </tr>
                 </thead>
                 <tbody class="bg-white divide-y divide-apex-border">
@@ -374,6 +376,8 @@ function displayAnalytics(data) {
                             <td class="px-6 py-4 whitespace-nowrap text-sm font-mono text-apex-text">${formatNumber(s.total_tokens)}</td>
                             <td class="px-6 py-4 whitespace-nowrap text-sm font-mono text-apex-muted">${formatNumber(s.total_input_tokens)}</td>
                             <td class="px-6 py-4 whitespace-nowrap text-sm font-mono text-apex-muted">${formatNumber(s.total_output_tokens)}</td>
+                            <td class="px-6 py-4 whitespace-nowrap text-sm font-mono text-apex-muted">${formatNumber(s.total_cache_creation_tokens || 0)}</td>
+                            <td class="px-6 py-4 whitespace-nowrap text-sm font-mono text-apex-muted">${formatNumber(s.total_cache_read_tokens || 0)}</td>
                         </tr>
                     `}).join('')}
                 </tbody>